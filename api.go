@@ -1,8 +1,12 @@
 package gotrue
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/google/uuid"
+
+	"github.com/supabase-community/gotrue-go/pagination"
 	"github.com/supabase-community/gotrue-go/types"
 )
 
@@ -41,6 +45,100 @@ type Client interface {
 	// It returns a copy of the client, so only requests made with the returned
 	// copy will use the new HTTP client.
 	WithClient(client http.Client) Client
+	// WithContext sets the context to use for all requests made by the client.
+	//
+	// It returns a copy of the client, so only requests made with the returned
+	// copy will use the new context.
+	//
+	// Cancelling the context, or letting its deadline pass, aborts any request
+	// in flight. This is typically used to tie auth calls to the lifetime of an
+	// inbound HTTP request:
+	//
+	//	user, err := client.WithContext(r.Context()).GetUser()
+	//
+	// SAMLACS is the exception: it sends the request you pass in, so it uses
+	// that request's context instead.
+	WithContext(ctx context.Context) Client
+	// WithSessionStore sets a store used to persist the user's session.
+	//
+	// It returns a copy of the client, so only requests made with the returned
+	// copy will use the store.
+	//
+	// The session is saved under key after each successful call to Token
+	// (including the SignInWith... and RefreshToken convenience methods) and
+	// removed after Logout. Use RestoreSession to load it again, e.g. after a
+	// restart.
+	//
+	// If the session can't be saved, the method returns its response along
+	// with a *SessionStoreError, so that the new session is not lost.
+	//
+	// See NewMemorySessionStore and NewFileSessionStore for the built-in
+	// stores.
+	WithSessionStore(store SessionStore, key string) Client
+	// WithSendLimiter sets a limiter that rejects OTP, Magiclink, Recover and
+	// Signup requests for an email address or phone number that was sent to
+	// too recently, without sending the request to the server.
+	//
+	// It returns a copy of the client, so only requests made with the returned
+	// copy will use the limiter.
+	//
+	// Rejected requests return a *types.RateLimitError with RetryAfter set to
+	// how long until a send is allowed again. If the server rate limits a
+	// request, the limiter also waits for as long as the server asks.
+	WithSendLimiter(limiter *SendLimiter) Client
+	// WithRetryPolicy sets how requests that fail with a transient error, such
+	// as a dropped connection or a 503 response, are retried. By default,
+	// requests are not retried.
+	//
+	// It returns a copy of the client, so only requests made with the returned
+	// copy will use the policy.
+	//
+	// Only requests that are safe to repeat, such as GetUser or GetSettings,
+	// are retried, unless the policy sets RetryNonIdempotent. To opt in for a
+	// single call, use a copy of the client:
+	//
+	//	policy := types.DefaultRetryPolicy
+	//	policy.RetryNonIdempotent = true
+	//	resp, err := client.WithRetryPolicy(policy).Signup(req)
+	WithRetryPolicy(policy types.RetryPolicy) Client
+	// WithMiddleware wraps the transport of the HTTP client in middleware, to
+	// inspect or modify every request the client sends, e.g. to add request
+	// IDs, set headers such as X-Client-Info or X-Forwarded-For, or log
+	// requests. The first middleware is the outermost. Retries pass through
+	// the middleware again.
+	//
+	// It returns a copy of the client, so only requests made with the returned
+	// copy will use the middleware. Middleware is added to any already set.
+	//
+	// Use types.SetHeaders to set headers per request:
+	//
+	//	client = client.WithMiddleware(types.SetHeaders(func(ctx context.Context, h http.Header) {
+	//		h.Set("X-Request-Id", requestIDFromContext(ctx))
+	//	}))
+	WithMiddleware(middleware ...types.Middleware) Client
+	// WithOperationHook calls hook whenever a client method starts, e.g. to
+	// start a span covering all of the requests the method sends, including
+	// retries. Methods called by other methods, such as GetUser by
+	// ListFactors, are part of the outer method's operation.
+	//
+	// It returns a copy of the client, so only methods called on the returned
+	// copy will call the hook. The hook is added to any already set.
+	WithOperationHook(hook types.OperationHook) Client
+
+	// RestoreSession loads the session saved by the session store. If the
+	// session has expired, or is about to, it is refreshed first and the new
+	// session is saved. The expiry is taken from ExpiresAt or, if it is not
+	// set, the access token. Sessions whose expiry is unknown are returned
+	// without refreshing.
+	//
+	// Returns ErrNoSessionStore if no store has been set with
+	// WithSessionStore, or ErrSessionNotFound if there is no saved session.
+	// If the refreshed session can't be saved, it is returned along with a
+	// *SessionStoreError.
+	//
+	// To make requests on behalf of the user, pass the access token of the
+	// returned session to WithToken.
+	RestoreSession() (*types.Session, error)
 
 	// Endpoints:
 
@@ -56,6 +154,15 @@ type Client interface {
 	// will include the total number of results, as well as the total number of pages
 	// and, if not already on the last page, the next page number.
 	AdminAudit(req types.AdminAuditRequest) (*types.AdminAuditResponse, error)
+	// Iterate over all audit log entries matching the request.
+	//
+	// Pages are fetched lazily as the iterator advances, starting at req.Page
+	// (the first page if 0). Use req.PerPage to set the page size, 50 by
+	// default, and opts.Prefetch to fetch pages ahead concurrently.
+	//
+	// The context is used for all page requests. Cancelling it, or calling
+	// Close on the iterator, stops iteration.
+	AdminAuditAll(ctx context.Context, req types.AdminAuditRequest, opts pagination.Options) *pagination.Iterator[types.AuditLogEntry]
 
 	// POST /admin/generate_link
 	//
@@ -98,8 +205,27 @@ type Client interface {
 	//
 	// Get a list of users.
 	//
+	// May optionally specify a filter, to only return users whose email or name
+	// contains a value, and a column to sort by.
+	//
+	// The result may also be paginated. By default, 50 results will be returned
+	// per request. This can be configured with PerPage in the request. The response
+	// will include the total number of results, as well as the total number of pages
+	// and, if not already on the last page, the next page number.
+	//
 	// Requires admin token.
-	AdminListUsers() (*types.AdminListUsersResponse, error)
+	AdminListUsers(req types.AdminListUsersRequest) (*types.AdminListUsersResponse, error)
+	// Iterate over all users matching the request.
+	//
+	// Pages are fetched lazily as the iterator advances, starting at req.Page
+	// (the first page if 0). Use req.PerPage to set the page size, 50 by
+	// default, and opts.Prefetch to fetch pages ahead concurrently.
+	//
+	// The context is used for all page requests. Cancelling it, or calling
+	// Close on the iterator, stops iteration.
+	//
+	// Requires admin token.
+	AdminListUsersAll(ctx context.Context, req types.AdminListUsersRequest, opts pagination.Options) *pagination.Iterator[types.User]
 	// GET /admin/users/{user_id}
 	//
 	// Get a user by their user_id.
@@ -125,6 +251,26 @@ type Client interface {
 	//
 	// Delete a factor for a user.
 	AdminDeleteUserFactor(req types.AdminDeleteUserFactorRequest) error
+	// GET /admin/users/{user_id}/factors
+	// DELETE /admin/users/{user_id}/factors/{factor_id}
+	//
+	// Delete a user's factors, optionally filtered by type and status, e.g. to
+	// reset MFA for a locked out user. The response lists the deleted
+	// factors. If a deletion fails, the response so far is returned along
+	// with the error.
+	AdminDeleteUserFactors(req types.AdminDeleteUserFactorsRequest) (*types.AdminDeleteUserFactorsResponse, error)
+
+	// POST /logout?scope={scope}
+	//
+	// Sign out the user that req.AccessToken belongs to, revoking the sessions
+	// selected by req.Scope. The request is authenticated with the access
+	// token instead of the client's token.
+	//
+	// GoTrue has no admin endpoints to sign out a user by their ID, or to list
+	// or revoke a user's sessions. To cut off a user without their access
+	// token, ban them with AdminUpdateUser, or delete them with
+	// AdminDeleteUser.
+	AdminSignOut(req types.AdminSignOutRequest) error
 
 	// GET /authorize
 	//
@@ -136,20 +282,54 @@ type Client interface {
 	// If successful, the server returns a redirect response. This method will not
 	// follow the redirect, but instead returns the URL the client was told to
 	// redirect to.
+	//
+	// If SkipHTTPRedirect is true, no request is made. Instead, the URL of this
+	// endpoint is built locally and returned, for the user's browser to visit.
 	Authorize(req types.AuthorizeRequest) (*types.AuthorizeResponse, error)
 
 	// POST /factors
 	//
-	// Enroll a new factor.
+	// Enroll a new factor. FactorType defaults to phone if Phone is set, or
+	// TOTP otherwise.
 	EnrollFactor(req types.EnrollFactorRequest) (*types.EnrollFactorResponse, error)
 	// POST /factors/{factor_id}/challenge
 	//
-	// Challenge a factor.
+	// Challenge a factor. For phone factors, this sends a code to the factor's
+	// phone number over Channel, which defaults to SMS.
 	ChallengeFactor(req types.ChallengeFactorRequest) (*types.ChallengeFactorResponse, error)
 	// POST /factors/{factor_id}/verify
 	//
 	// Verify the challenge for an enrolled factor.
 	VerifyFactor(req types.VerifyFactorRequest) (*types.VerifyFactorResponse, error)
+	// GET /user
+	//
+	// Get the authenticator assurance level of the client's access token, the
+	// level the user could reach by verifying one of their factors, and the
+	// authentication methods used to obtain the session.
+	//
+	// The level is read from the claims of the access token, and the user's
+	// factors are fetched with GetUser. Requires authentication.
+	//
+	// If NextLevel is higher than CurrentLevel, the user has a verified factor
+	// and should be asked to verify it, e.g. with ChallengeFactor and
+	// VerifyFactor.
+	GetAuthenticatorAssuranceLevel() (*types.AuthenticatorAssuranceLevelResponse, error)
+	// Challenge and verify a factor in one go
+	//
+	// This is a convenience method that calls ChallengeFactor, then
+	// VerifyFactor with the new challenge and the code, and returns the
+	// upgraded session. If the challenge expires before it is verified, a new
+	// challenge is created and verified once more.
+	//
+	// It is only useful for TOTP factors, where the code doesn't depend on the
+	// challenge. For phone factors, call ChallengeFactor to send the code
+	// first.
+	ChallengeAndVerify(factorID uuid.UUID, code string) (*types.VerifyFactorResponse, error)
+	// GET /user
+	//
+	// List the user's factors, split into verified and unverified, and by
+	// type. Requires authentication.
+	ListFactors() (*types.ListFactorsResponse, error)
 	// DELETE /factors/{factor_id}
 	//
 	// Unenroll an enrolled factor.
@@ -180,7 +360,20 @@ type Client interface {
 	//
 	// This will revoke all refresh tokens for the user. Remember that the JWT
 	// tokens will still be valid for stateless auth until they expires.
+	//
+	// If a session store is set, the stored session is removed, even if the
+	// request fails.
 	Logout() error
+	// POST /logout?scope={scope}
+	//
+	// Logout a user (Requires authentication), revoking the sessions selected
+	// by scope: all of the user's sessions (LogoutScopeGlobal, the default),
+	// only the current one (LogoutScopeLocal), or all but the current one
+	// (LogoutScopeOthers).
+	//
+	// If a session store is set, the stored session is removed, even if the
+	// request fails, unless scope is LogoutScopeOthers.
+	LogoutWithScope(scope types.LogoutScope) error
 
 	// POST /magiclink
 	//
@@ -191,6 +384,12 @@ type Client interface {
 	// address which they can use to redeem an access_token.
 	//
 	// By default Magic Links can only be sent once every 60 seconds.
+	//
+	// Set CodeChallenge and CodeChallengeMethod on the request to use the PKCE
+	// flow. See types.GeneratePKCEParams and ExchangeCodeForSession.
+	//
+	// If the request is rate limited, the error is a *types.RateLimitError.
+	// See also WithSendLimiter.
 	Magiclink(req types.MagiclinkRequest) error
 	// POST /otp
 	// One-Time-Password. Will deliver a magiclink or SMS OTP to the user depending
@@ -198,6 +397,12 @@ type Client interface {
 	//
 	// If CreateUser is true, the user will be automatically signed up if the user
 	// doesn't exist.
+	//
+	// Set CodeChallenge and CodeChallengeMethod on the request to use the PKCE
+	// flow. See types.GeneratePKCEParams and ExchangeCodeForSession.
+	//
+	// If the request is rate limited, the error is a *types.RateLimitError.
+	// See also WithSendLimiter.
 	OTP(req types.OTPRequest) error
 
 	// GET /reauthenticate
@@ -213,6 +418,12 @@ type Client interface {
 	// on email address.
 	//
 	// By default recovery links can only be sent once every 60 seconds.
+	//
+	// Set CodeChallenge and CodeChallengeMethod on the request to use the PKCE
+	// flow. See types.GeneratePKCEParams and ExchangeCodeForSession.
+	//
+	// If the request is rate limited, the error is a *types.RateLimitError.
+	// See also WithSendLimiter.
 	Recover(req types.RecoverRequest) error
 
 	// GET /settings
@@ -223,8 +434,29 @@ type Client interface {
 	// POST /signup
 	//
 	// Register a new user with an email and password.
+	//
+	// Set CodeChallenge and CodeChallengeMethod on the request to use the PKCE
+	// flow. See types.GeneratePKCEParams and ExchangeCodeForSession.
+	//
+	// If the request is rate limited, the error is a *types.RateLimitError.
+	// See also WithSendLimiter.
 	Signup(req types.SignupRequest) (*types.SignupResponse, error)
 
+	// POST /signup
+	//
+	// Create a new anonymous user and sign them in. Anonymous sign-ins must be
+	// enabled on the server, see ExternalProviders.AnonymousUsers in the
+	// response of GetSettings.
+	//
+	// The user has IsAnonymous set, as do the claims of their access token.
+	// To convert them into a permanent user, call UpdateUser with their access
+	// token to add an email or phone, and a password. Once the email or phone
+	// is confirmed, IsAnonymous is cleared and the user keeps their ID and
+	// data.
+	//
+	// If a session store is set, the session is saved like with Token.
+	SignInAnonymously(req types.SignInAnonymouslyRequest) (*types.SignInAnonymouslyResponse, error)
+
 	// Sign in with email and password
 	//
 	// This is a convenience method that calls Token with the password grant type
@@ -237,10 +469,31 @@ type Client interface {
 	//
 	// This is a convenience method that calls Token with the refresh_token grant type
 	RefreshToken(refreshToken string) (*types.TokenResponse, error)
+	// Exchange an auth code for a session
+	//
+	// This is a convenience method that calls Token with the pkce grant type.
+	// code is the code the user was redirected back with, and codeVerifier is
+	// the Verifier of the PKCE params used to start the flow, either returned
+	// by Authorize or generated with types.GeneratePKCEParams.
+	ExchangeCodeForSession(code, codeVerifier string) (*types.TokenResponse, error)
+	// Sign in with an ID token
+	//
+	// This is a convenience method that calls Token with the id_token grant
+	// type. Use it to exchange an ID token obtained with a provider's native
+	// sign-in SDK, e.g. Sign in with Apple on iOS, for a session. nonce may be
+	// empty if no nonce was used.
+	//
+	// The provider must be enabled on the server. To also pass the provider's
+	// access token, call Token directly.
+	SignInWithIDToken(provider types.Provider, idToken, nonce string) (*types.TokenResponse, error)
 	// POST /token
 	//
-	// This is an OAuth2 endpoint that currently implements the password and
-	// refresh_token grant types
+	// This is an OAuth2 endpoint that currently implements the password,
+	// refresh_token, PKCE and id_token grant types
+	//
+	// If a session store is set, the new session is saved to it. Should saving
+	// fail, the response is still returned, along with the error, so that the
+	// session is not lost.
 	Token(req types.TokenRequest) (*types.TokenResponse, error)
 
 	// GET /user
@@ -254,6 +507,30 @@ type Client interface {
 	// result in a magiclink being sent out.
 	UpdateUser(req types.UpdateUserRequest) (*types.UpdateUserResponse, error)
 
+	// GET /user
+	//
+	// Get the identities linked to the logged in user (requires
+	// authentication).
+	GetUserIdentities() (*types.GetUserIdentitiesResponse, error)
+	// GET /user/identities/authorize
+	//
+	// Get the URL to send the logged in user to, to link an identity from an
+	// external oauth provider to their account (requires authentication).
+	// Manual linking must be enabled on the server.
+	//
+	// If FlowType is FlowPKCE, exchange the code returned to RedirectTo and
+	// the returned Verifier with ExchangeCodeForSession.
+	LinkIdentity(req types.LinkIdentityRequest) (*types.LinkIdentityResponse, error)
+	// DELETE /user/identities/{identity_id}
+	//
+	// Unlink an identity from the logged in user (requires authentication).
+	// identityID is the identity's IdentityID. Manual linking must be enabled
+	// on the server.
+	//
+	// A user's last identity can't be unlinked; use
+	// types.IsIdentityNotDeletable to check for this error.
+	UnlinkIdentity(identityID uuid.UUID) error
+
 	// GET /verify
 	//
 	// Verify a registration or a password recovery. Type can be signup or recovery
@@ -1,10 +1,12 @@
 package gotrue
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/endpoints"
+	"github.com/supabase-community/gotrue-go/types"
 )
 
 var (
@@ -15,6 +17,13 @@ var _ Client = &client{}
 
 type client struct {
 	*endpoints.Client
+
+	// Optional session persistence, see WithSessionStore.
+	store      SessionStore
+	storageKey string
+
+	// Optional client-side send limits, see WithSendLimiter.
+	limiter *SendLimiter
 }
 
 // Set up a new GoTrue client.
@@ -35,19 +44,47 @@ func New(projectReference string, apiKey string) Client {
 }
 
 func (c client) WithCustomGoTrueURL(url string) Client {
-	return &client{
-		Client: c.Client.WithCustomGoTrueURL(url),
-	}
+	c.Client = c.Client.WithCustomGoTrueURL(url)
+	return &c
 }
 
 func (c client) WithToken(token string) Client {
-	return &client{
-		Client: c.Client.WithToken(token),
-	}
+	c.Client = c.Client.WithToken(token)
+	return &c
 }
 
 func (c client) WithClient(httpClient http.Client) Client {
-	return &client{
-		Client: c.Client.WithClient(httpClient),
-	}
+	c.Client = c.Client.WithClient(httpClient)
+	return &c
+}
+
+func (c client) WithContext(ctx context.Context) Client {
+	c.Client = c.Client.WithContext(ctx)
+	return &c
+}
+
+func (c client) WithSendLimiter(limiter *SendLimiter) Client {
+	c.limiter = limiter
+	return &c
+}
+
+func (c client) WithRetryPolicy(policy types.RetryPolicy) Client {
+	c.Client = c.Client.WithRetryPolicy(policy)
+	return &c
+}
+
+func (c client) WithMiddleware(middleware ...types.Middleware) Client {
+	c.Client = c.Client.WithMiddleware(middleware...)
+	return &c
+}
+
+func (c client) WithOperationHook(hook types.OperationHook) Client {
+	c.Client = c.Client.WithOperationHook(hook)
+	return &c
+}
+
+func (c client) WithSessionStore(store SessionStore, key string) Client {
+	c.store = store
+	c.storageKey = key
+	return &c
 }
@@ -0,0 +1,45 @@
+package endpoints
+
+import (
+	"fmt"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// GET /user
+//
+// Get the authenticator assurance level of the client's access token, the
+// level the user could reach by verifying one of their factors, and the
+// authentication methods used to obtain the session.
+//
+// The level is read from the claims of the access token, and the user's
+// factors are fetched with GetUser.
+func (c *Client) GetAuthenticatorAssuranceLevel() (_ *types.AuthenticatorAssuranceLevelResponse, err error) {
+	c, op := c.startOperation("GetAuthenticatorAssuranceLevel")
+	defer op.end(&err)
+
+	// Fetching the user also checks that the token is valid, so the claims
+	// below can be trusted.
+	user, err := c.GetUser()
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := types.ParseClaims(c.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse access token: %w", err)
+	}
+
+	res := &types.AuthenticatorAssuranceLevelResponse{
+		CurrentLevel:                 claims.AAL,
+		NextLevel:                    claims.AAL,
+		CurrentAuthenticationMethods: claims.AMR,
+	}
+	for _, f := range user.Factors {
+		if f.Status == types.FactorStatusVerified {
+			res.NextLevel = types.AAL2
+			break
+		}
+	}
+	return res, nil
+}
@@ -3,12 +3,7 @@ package endpoints
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
-	"strconv"
-
-	"github.com/tomnomnom/linkheader"
 
 	"github.com/supabase-community/gotrue-go/types"
 )
@@ -26,7 +21,10 @@ const adminAuditPath = "/admin/audit"
 // per request. This can be configured with PerPage in the request. The response
 // will include the total number of results, as well as the total number of pages
 // and, if not already on the last page, the next page number.
-func (c *Client) AdminAudit(req types.AdminAuditRequest) (*types.AdminAuditResponse, error) {
+func (c *Client) AdminAudit(req types.AdminAuditRequest) (_ *types.AdminAuditResponse, err error) {
+	c, op := c.startOperation("AdminAudit")
+	defer op.end(&err)
+
 	if req.Query != nil {
 		if req.Query.Column != types.AuditQueryColumnAuthor && req.Query.Column != types.AuditQueryColumnAction && req.Query.Column != types.AuditQueryColumnType {
 			return nil, types.ErrInvalidAdminAuditRequest
@@ -51,18 +49,14 @@ func (c *Client) AdminAudit(req types.AdminAuditRequest) (*types.AdminAuditRespo
 	}
 	r.URL.RawQuery = q.Encode()
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var logs []types.AuditLogEntry
@@ -70,51 +64,13 @@ func (c *Client) AdminAudit(req types.AdminAuditRequest) (*types.AdminAuditRespo
 		return nil, err
 	}
 
-	// Result count should be given in X-Total-Count header.
-	count := resp.Header.Get("X-Total-Count")
-	resultCount := 0
-	if count != "" {
-		resultCount, _ = strconv.Atoi(count)
-	}
-
-	// Parse Link header from response to get total pages
-	links := linkheader.Parse(resp.Header.Get("Link"))
-
-	// Header should only contain one 'last' link
-	var totPages uint = req.Page
-	l := links.FilterByRel("last")
-	if len(l) == 1 {
-		// Parse it's URL as a URL to get the query params
-		lastURL, err := url.Parse(l[0].URL)
-		if err == nil {
-			// Look for the ?page=X query param
-			last := lastURL.Query().Get("page")
-			lastPage, err := strconv.Atoi(last)
-			if err == nil {
-				totPages = uint(lastPage)
-			}
-		}
-	}
-
-	// Header may contain one 'next' link
-	var nextPage uint = 0
-	n := links.FilterByRel("next")
-	if len(n) == 1 {
-		nextURL, err := url.Parse(n[0].URL)
-		if err == nil {
-			next := nextURL.Query().Get("page")
-			nPage, err := strconv.Atoi(next)
-			if err == nil {
-				nextPage = uint(nPage)
-			}
-		}
-	}
+	p := parsePagination(resp, req.Page)
 
 	return &types.AdminAuditResponse{
 		Logs: logs,
 
-		TotalCount: resultCount,
-		NextPage:   nextPage,
-		TotalPages: totPages,
+		TotalCount: p.totalCount,
+		NextPage:   p.nextPage,
+		TotalPages: p.totalPages,
 	}, nil
 }
@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -56,8 +54,11 @@ func validateAdminGenerateLinkRequest(req types.AdminGenerateLinkRequest) error
 // Among other things, the response also contains the query params of the action
 // link as separate JSON fields for convenience (along with the email OTP from
 // which the corresponding token is generated).
-func (c *Client) AdminGenerateLink(req types.AdminGenerateLinkRequest) (*types.AdminGenerateLinkResponse, error) {
-	err := validateAdminGenerateLinkRequest(req)
+func (c *Client) AdminGenerateLink(req types.AdminGenerateLinkRequest) (_ *types.AdminGenerateLinkResponse, err error) {
+	c, op := c.startOperation("AdminGenerateLink")
+	defer op.end(&err)
+
+	err = validateAdminGenerateLinkRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -72,18 +73,14 @@ func (c *Client) AdminGenerateLink(req types.AdminGenerateLinkRequest) (*types.A
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminGenerateLinkResponse
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -15,24 +14,23 @@ const adminSSOPath = "/admin/sso/providers"
 // GET /admin/sso/providers
 //
 // Get a list of all SAML SSO Identity Providers in the system.
-func (c *Client) AdminListSSOProviders() (*types.AdminListSSOProvidersResponse, error) {
+func (c *Client) AdminListSSOProviders() (_ *types.AdminListSSOProvidersResponse, err error) {
+	c, op := c.startOperation("AdminListSSOProviders")
+	defer op.end(&err)
+
 	r, err := c.newRequest(adminSSOPath, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminListSSOProvidersResponse
@@ -47,7 +45,10 @@ func (c *Client) AdminListSSOProviders() (*types.AdminListSSOProvidersResponse,
 // POST /admin/sso/providers
 //
 // Create a new SAML SSO Identity Provider.
-func (c *Client) AdminCreateSSOProvider(req types.AdminCreateSSOProviderRequest) (*types.AdminCreateSSOProviderResponse, error) {
+func (c *Client) AdminCreateSSOProvider(req types.AdminCreateSSOProviderRequest) (_ *types.AdminCreateSSOProviderResponse, err error) {
+	c, op := c.startOperation("AdminCreateSSOProvider")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -58,18 +59,14 @@ func (c *Client) AdminCreateSSOProvider(req types.AdminCreateSSOProviderRequest)
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminCreateSSOProviderResponse
@@ -84,24 +81,23 @@ func (c *Client) AdminCreateSSOProvider(req types.AdminCreateSSOProviderRequest)
 // GET /admin/sso/providers/{idp_id}
 //
 // Get a SAML SSO Identity Provider by ID.
-func (c *Client) AdminGetSSOProvider(req types.AdminGetSSOProviderRequest) (*types.AdminGetSSOProviderResponse, error) {
+func (c *Client) AdminGetSSOProvider(req types.AdminGetSSOProviderRequest) (_ *types.AdminGetSSOProviderResponse, err error) {
+	c, op := c.startOperation("AdminGetSSOProvider")
+	defer op.end(&err)
+
 	r, err := c.newRequest(fmt.Sprintf("%s/%s", adminSSOPath, req.ProviderID), http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminGetSSOProviderResponse
@@ -116,7 +112,10 @@ func (c *Client) AdminGetSSOProvider(req types.AdminGetSSOProviderRequest) (*typ
 // PUT /admin/sso/providers/{idp_id}
 //
 // Update a SAML SSO Identity Provider by ID.
-func (c *Client) AdminUpdateSSOProvider(req types.AdminUpdateSSOProviderRequest) (*types.AdminUpdateSSOProviderResponse, error) {
+func (c *Client) AdminUpdateSSOProvider(req types.AdminUpdateSSOProviderRequest) (_ *types.AdminUpdateSSOProviderResponse, err error) {
+	c, op := c.startOperation("AdminUpdateSSOProvider")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -127,18 +126,14 @@ func (c *Client) AdminUpdateSSOProvider(req types.AdminUpdateSSOProviderRequest)
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminUpdateSSOProviderResponse
@@ -153,25 +148,24 @@ func (c *Client) AdminUpdateSSOProvider(req types.AdminUpdateSSOProviderRequest)
 // DELETE /admin/sso/providers/{idp_id}
 //
 // Delete a SAML SSO Identity Provider by ID.
-func (c *Client) AdminDeleteSSOProvider(req types.AdminDeleteSSOProviderRequest) (*types.AdminDeleteSSOProviderResponse, error) {
+func (c *Client) AdminDeleteSSOProvider(req types.AdminDeleteSSOProviderRequest) (_ *types.AdminDeleteSSOProviderResponse, err error) {
+	c, op := c.startOperation("AdminDeleteSSOProvider")
+	defer op.end(&err)
+
 	path := fmt.Sprintf("%s/%s", adminSSOPath, req.ProviderID)
 	r, err := c.newRequest(path, http.MethodDelete, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminDeleteSSOProviderResponse
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -15,7 +14,10 @@ const adminUsersPath = "/admin/users"
 // POST /admin/users
 //
 // Creates the user based on the user_id specified.
-func (c *Client) AdminCreateUser(req types.AdminCreateUserRequest) (*types.AdminCreateUserResponse, error) {
+func (c *Client) AdminCreateUser(req types.AdminCreateUserRequest) (_ *types.AdminCreateUserResponse, err error) {
+	c, op := c.startOperation("AdminCreateUser")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -26,18 +28,14 @@ func (c *Client) AdminCreateUser(req types.AdminCreateUserRequest) (*types.Admin
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminCreateUserResponse
@@ -52,24 +50,55 @@ func (c *Client) AdminCreateUser(req types.AdminCreateUserRequest) (*types.Admin
 // GET /admin/users
 //
 // Get a list of users.
-func (c *Client) AdminListUsers() (*types.AdminListUsersResponse, error) {
+//
+// May optionally specify a filter, to only return users whose email or name
+// contains a value, and a column to sort by.
+//
+// The result may also be paginated. By default, 50 results will be returned
+// per request. This can be configured with PerPage in the request. The response
+// will include the total number of results, as well as the total number of pages
+// and, if not already on the last page, the next page number.
+func (c *Client) AdminListUsers(req types.AdminListUsersRequest) (_ *types.AdminListUsersResponse, err error) {
+	c, op := c.startOperation("AdminListUsers")
+	defer op.end(&err)
+
+	if req.Sort != nil {
+		if req.Sort.Column == "" {
+			return nil, types.ErrInvalidAdminListUsersRequest
+		}
+		if req.Sort.Direction != types.SortAscending && req.Sort.Direction != types.SortDescending {
+			return nil, types.ErrInvalidAdminListUsersRequest
+		}
+	}
+
 	r, err := c.newRequest(adminUsersPath, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	q := r.URL.Query()
+	if req.Filter != "" {
+		q.Add("filter", req.Filter)
+	}
+	if req.Sort != nil {
+		q.Add("sort", fmt.Sprintf("%s %s", req.Sort.Column, req.Sort.Direction))
+	}
+	if req.Page != 0 {
+		q.Add("page", fmt.Sprintf("%d", req.Page))
+	}
+	if req.PerPage != 0 {
+		q.Add("per_page", fmt.Sprintf("%d", req.PerPage))
+	}
+	r.URL.RawQuery = q.Encode()
+
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminListUsersResponse
@@ -78,31 +107,40 @@ func (c *Client) AdminListUsers() (*types.AdminListUsersResponse, error) {
 		return nil, err
 	}
 
+	page := req.Page
+	if page == 0 {
+		// The server defaults to the first page.
+		page = 1
+	}
+	p := parsePagination(resp, page)
+	res.TotalCount = p.totalCount
+	res.TotalPages = p.totalPages
+	res.NextPage = p.nextPage
+
 	return &res, nil
 }
 
 // GET /admin/users/{user_id}
 //
 // Get a user by their user_id.
-func (c *Client) AdminGetUser(req types.AdminGetUserRequest) (*types.AdminGetUserResponse, error) {
+func (c *Client) AdminGetUser(req types.AdminGetUserRequest) (_ *types.AdminGetUserResponse, err error) {
+	c, op := c.startOperation("AdminGetUser")
+	defer op.end(&err)
+
 	path := fmt.Sprintf("%s/%s", adminUsersPath, req.UserID)
 	r, err := c.newRequest(path, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminGetUserResponse
@@ -117,7 +155,10 @@ func (c *Client) AdminGetUser(req types.AdminGetUserRequest) (*types.AdminGetUse
 // PUT /admin/users/{user_id}
 //
 // Update a user by their user_id.
-func (c *Client) AdminUpdateUser(req types.AdminUpdateUserRequest) (*types.AdminUpdateUserResponse, error) {
+func (c *Client) AdminUpdateUser(req types.AdminUpdateUserRequest) (_ *types.AdminUpdateUserResponse, err error) {
+	c, op := c.startOperation("AdminUpdateUser")
+	defer op.end(&err)
+
 	path := fmt.Sprintf("%s/%s", adminUsersPath, req.UserID)
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -129,18 +170,14 @@ func (c *Client) AdminUpdateUser(req types.AdminUpdateUserRequest) (*types.Admin
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminUpdateUserResponse
@@ -155,25 +192,24 @@ func (c *Client) AdminUpdateUser(req types.AdminUpdateUserRequest) (*types.Admin
 // DELETE /admin/users/{user_id}
 //
 // Delete a user by their user_id.
-func (c *Client) AdminDeleteUser(req types.AdminDeleteUserRequest) error {
+func (c *Client) AdminDeleteUser(req types.AdminDeleteUserRequest) (err error) {
+	c, op := c.startOperation("AdminDeleteUser")
+	defer op.end(&err)
+
 	path := fmt.Sprintf("%s/%s", adminUsersPath, req.UserID)
 	r, err := c.newRequest(path, http.MethodDelete, nil)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return handleErrorResponse(resp)
 	}
 
 	return nil
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -13,7 +12,10 @@ import (
 // GET /admin/users/{user_id}/factors
 //
 // Get a list of factors for a user.
-func (c *Client) AdminListUserFactors(req types.AdminListUserFactorsRequest) (*types.AdminListUserFactorsResponse, error) {
+func (c *Client) AdminListUserFactors(req types.AdminListUserFactorsRequest) (_ *types.AdminListUserFactorsResponse, err error) {
+	c, op := c.startOperation("AdminListUserFactors")
+	defer op.end(&err)
+
 	path := fmt.Sprintf("%s/%s/factors", adminUsersPath, req.UserID)
 
 	r, err := c.newRequest(path, http.MethodGet, nil)
@@ -21,18 +23,14 @@ func (c *Client) AdminListUserFactors(req types.AdminListUserFactorsRequest) (*t
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var factors []types.Factor
@@ -49,7 +47,10 @@ func (c *Client) AdminListUserFactors(req types.AdminListUserFactorsRequest) (*t
 // PUT /admin/users/{user_id}/factors/{factor_id}
 //
 // Update a factor for a user.
-func (c *Client) AdminUpdateUserFactor(req types.AdminUpdateUserFactorRequest) (*types.AdminUpdateUserFactorResponse, error) {
+func (c *Client) AdminUpdateUserFactor(req types.AdminUpdateUserFactorRequest) (_ *types.AdminUpdateUserFactorResponse, err error) {
+	c, op := c.startOperation("AdminUpdateUserFactor")
+	defer op.end(&err)
+
 	if req.FriendlyName == "" {
 		return nil, types.ErrInvalidAdminUpdateFactorRequest
 	}
@@ -66,18 +67,14 @@ func (c *Client) AdminUpdateUserFactor(req types.AdminUpdateUserFactorRequest) (
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.AdminUpdateUserFactorResponse
@@ -92,7 +89,10 @@ func (c *Client) AdminUpdateUserFactor(req types.AdminUpdateUserFactorRequest) (
 // DELETE /admin/users/{user_id}/factors/{factor_id}
 //
 // Delete a factor for a user.
-func (c *Client) AdminDeleteUserFactor(req types.AdminDeleteUserFactorRequest) error {
+func (c *Client) AdminDeleteUserFactor(req types.AdminDeleteUserFactorRequest) (err error) {
+	c, op := c.startOperation("AdminDeleteUserFactor")
+	defer op.end(&err)
+
 	path := fmt.Sprintf("%s/%s/factors/%s", adminUsersPath, req.UserID, req.FactorID)
 
 	r, err := c.newRequest(path, http.MethodDelete, nil)
@@ -100,19 +100,54 @@ func (c *Client) AdminDeleteUserFactor(req types.AdminDeleteUserFactorRequest) e
 		return err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return handleErrorResponse(resp)
 	}
 
 	return nil
 }
+
+// GET /admin/users/{user_id}/factors
+// DELETE /admin/users/{user_id}/factors/{factor_id}
+//
+// Delete a user's factors, optionally only those of some types or statuses,
+// e.g. to reset MFA for a user who has lost access to their factors.
+//
+// Factors are deleted one at a time. If a deletion fails, the response listing
+// the factors deleted so far is returned along with the error. Factors that
+// were deleted by another request in the meantime are skipped.
+func (c *Client) AdminDeleteUserFactors(req types.AdminDeleteUserFactorsRequest) (_ *types.AdminDeleteUserFactorsResponse, err error) {
+	c, op := c.startOperation("AdminDeleteUserFactors")
+	defer op.end(&err)
+
+	factors, err := c.AdminListUserFactors(types.AdminListUserFactorsRequest{
+		UserID: req.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &types.AdminDeleteUserFactorsResponse{
+		UserID: req.UserID,
+	}
+	for _, f := range factors.Filter(req.FactorTypes, req.Statuses) {
+		err := c.AdminDeleteUserFactor(types.AdminDeleteUserFactorRequest{
+			UserID:   req.UserID,
+			FactorID: f.ID,
+		})
+		if types.HasErrorCode(err, types.ErrorCodeMFAFactorNotFound) {
+			continue
+		}
+		if err != nil {
+			return res, fmt.Errorf("failed to delete factor %s: %w", f.ID, err)
+		}
+		res.Deleted = append(res.Deleted, f)
+	}
+	return res, nil
+}
@@ -1,35 +1,15 @@
 package endpoints
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/supabase-community/gotrue-go/types"
 )
 
 const authorizePath = "/authorize"
 
-func generatePKCEParams() (*types.PKCEParams, error) {
-	data := make([]byte, 32)
-	if _, err := io.ReadFull(rand.Reader, data); err != nil {
-		return nil, err
-	}
-
-	// RawURLEncoding since "code challenge can only contain alphanumeric characters, hyphens, periods, underscores and tildes"
-	verifier := base64.RawURLEncoding.EncodeToString(data)
-	sha := sha256.Sum256([]byte(verifier))
-	challenge := base64.RawURLEncoding.EncodeToString(sha[:])
-	return &types.PKCEParams{
-		Challenge:       challenge,
-		ChallengeMethod: "S256",
-		Verifier:        verifier,
-	}, nil
-}
-
 // GET /authorize
 //
 // Get access_token from external oauth provider.
@@ -40,45 +20,47 @@ func generatePKCEParams() (*types.PKCEParams, error) {
 // If successful, the server returns a redirect response. This method will not
 // follow the redirect, but instead returns the URL the client was told to
 // redirect to.
-func (c *Client) Authorize(req types.AuthorizeRequest) (*types.AuthorizeResponse, error) {
+//
+// If SkipHTTPRedirect is true, no request is made. Instead, the URL of this
+// endpoint is built locally and returned, for the user's browser to visit.
+func (c *Client) Authorize(req types.AuthorizeRequest) (_ *types.AuthorizeResponse, err error) {
+	c, op := c.startOperation("Authorize")
+	defer op.end(&err)
+
+	if req.Provider == "" {
+		return nil, types.ErrInvalidAuthorizeRequest
+	}
+
 	r, err := c.newRequest(authorizePath, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	q := r.URL.Query()
-	q.Add("scopes", req.Scopes)
-	q.Add("provider", string(req.Provider))
-
-	verifier := ""
-
-	if string(req.FlowType) == string(types.FlowPKCE) {
-		pkce, err := generatePKCEParams()
-		if err != nil {
-			return nil, err
-		}
-		q.Add("code_challenge", pkce.Challenge)
-		q.Add("code_challenge_method", pkce.ChallengeMethod)
-		verifier = pkce.Verifier
+	verifier, err := setAuthorizeParams(q, req)
+	if err != nil {
+		return nil, err
 	}
-
 	r.URL.RawQuery = q.Encode()
 
+	if req.SkipHTTPRedirect {
+		return &types.AuthorizeResponse{
+			AuthorizationURL: r.URL.String(),
+			Verifier:         verifier,
+		}, nil
+	}
+
 	// Set up a client that will not follow the redirect.
 	noRedirClient := noRedirClient(c.client)
 
-	resp, err := noRedirClient.Do(r)
+	resp, err := c.doWith(noRedirClient, r, true)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusFound {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	url := resp.Header.Get("Location")
@@ -90,3 +72,29 @@ func (c *Client) Authorize(req types.AuthorizeRequest) (*types.AuthorizeResponse
 		Verifier:         verifier,
 	}, nil
 }
+
+// Set the query parameters of an authorize request, shared by Authorize and
+// LinkIdentity. If the PKCE flow is used, the verifier is returned.
+func setAuthorizeParams(q url.Values, req types.AuthorizeRequest) (string, error) {
+	// Provider specific parameters are passed on to the provider by the
+	// server. Add them first so they can't override the parameters below.
+	for k, v := range req.QueryParams {
+		q.Set(k, v)
+	}
+	q.Set("scopes", req.Scopes)
+	q.Set("provider", string(req.Provider))
+	if req.RedirectTo != "" {
+		q.Set("redirect_to", req.RedirectTo)
+	}
+
+	if string(req.FlowType) != string(types.FlowPKCE) {
+		return "", nil
+	}
+	pkce, err := types.GeneratePKCEParams()
+	if err != nil {
+		return "", err
+	}
+	q.Set("code_challenge", pkce.Challenge)
+	q.Set("code_challenge_method", pkce.ChallengeMethod)
+	return pkce.Verifier, nil
+}
@@ -1,9 +1,12 @@
 package endpoints
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/supabase-community/gotrue-go/types"
 )
 
 type Client struct {
@@ -11,6 +14,11 @@ type Client struct {
 	baseURL string
 	apiKey  string
 	token   string
+	ctx     context.Context
+
+	retryPolicy    types.RetryPolicy
+	middleware     []types.Middleware
+	operationHooks []types.OperationHook
 }
 
 func New(projectReference string, apiKey string) *Client {
@@ -21,34 +29,62 @@ func New(projectReference string, apiKey string) *Client {
 		},
 		baseURL: baseURL,
 		apiKey:  apiKey,
+		ctx:     context.Background(),
 	}
 }
 
 func (c Client) WithCustomGoTrueURL(url string) *Client {
-	return &Client{
-		client:  c.client,
-		baseURL: url,
-		apiKey:  c.apiKey,
-		token:   c.token,
-	}
+	c.baseURL = url
+	return &c
 }
 
 func (c Client) WithToken(token string) *Client {
-	return &Client{
-		client:  c.client,
-		baseURL: c.baseURL,
-		apiKey:  c.apiKey,
-		token:   token,
-	}
+	c.token = token
+	return &c
 }
 
 func (c Client) WithClient(client http.Client) *Client {
-	return &Client{
-		client:  client,
-		baseURL: c.baseURL,
-		apiKey:  c.apiKey,
-		token:   c.token,
+	c.client = client
+	return &c
+}
+
+func (c Client) WithContext(ctx context.Context) *Client {
+	c.ctx = ctx
+	return &c
+}
+
+func (c Client) WithRetryPolicy(policy types.RetryPolicy) *Client {
+	c.retryPolicy = policy
+	return &c
+}
+
+func (c Client) WithMiddleware(middleware ...types.Middleware) *Client {
+	// Copy, so that clients derived from the same parent don't share the
+	// backing array.
+	c.middleware = append(append([]types.Middleware{}, c.middleware...), middleware...)
+	return &c
+}
+
+func (c Client) WithOperationHook(hook types.OperationHook) *Client {
+	c.operationHooks = append(append([]types.OperationHook{}, c.operationHooks...), hook)
+	return &c
+}
+
+// Returns a copy of a HTTP client whose transport is wrapped in the client's
+// middleware. The first middleware is the outermost.
+func (c *Client) wrapTransport(client http.Client) http.Client {
+	if len(c.middleware) == 0 {
+		return client
+	}
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		transport = c.middleware[i](transport)
 	}
+	client.Transport = transport
+	return client
 }
 
 // Returns a copy of a HTTP client that will not follow redirects.
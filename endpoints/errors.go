@@ -0,0 +1,100 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// Build an *types.APIError from a non-success response, wrapped in a
+// *types.RateLimitError if the request was rate limited. The body of the
+// response is consumed, but not closed.
+func handleErrorResponse(resp *http.Response) error {
+	apiErr := decodeErrorResponse(resp)
+	if !types.IsRateLimited(apiErr) {
+		return apiErr
+	}
+
+	rlErr := &types.RateLimitError{APIError: apiErr}
+	if d, ok := parseRetryAfter(resp); ok {
+		rlErr.RetryAfter = d
+	} else if d, ok := parseRetryAfterMessage(apiErr.Message); ok {
+		rlErr.RetryAfter = d
+	}
+	return rlErr
+}
+
+// GoTrue reports the per-user limit on sending emails and SMS messages only in
+// the message, e.g. "For security purposes, you can only request this after
+// 42 seconds." Older versions say "once every 60 seconds".
+var retryAfterMessage = regexp.MustCompile(`(?:after|every) (\d+) seconds?`)
+
+func parseRetryAfterMessage(msg string) (time.Duration, bool) {
+	m := retryAfterMessage.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, false
+	}
+	secs, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+func decodeErrorResponse(resp *http.Response) *types.APIError {
+	apiErr := &types.APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("Sb-Request-Id")
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return apiErr
+	}
+	apiErr.Body = body
+
+	// GoTrue uses a few different shapes for error bodies, depending on the
+	// endpoint and server version. Decode all of the known fields and pick the
+	// first message that is set. If the body is not JSON, just keep the raw
+	// body.
+	var decoded struct {
+		Code             json.RawMessage            `json:"code"`
+		ErrorCode        types.ErrorCode            `json:"error_code"`
+		Msg              string                     `json:"msg"`
+		Message          string                     `json:"message"`
+		Error            string                     `json:"error"`
+		ErrorDescription string                     `json:"error_description"`
+		WeakPassword     *types.WeakPasswordDetails `json:"weak_password"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return apiErr
+	}
+	apiErr.ErrorCode = decoded.ErrorCode
+	// Older servers send the status code as "code". Newer ones send the
+	// error code as a string instead, and may omit "error_code".
+	var code int
+	var errorCode types.ErrorCode
+	if json.Unmarshal(decoded.Code, &code) == nil {
+		apiErr.Code = code
+	} else if json.Unmarshal(decoded.Code, &errorCode) == nil && apiErr.ErrorCode == "" {
+		apiErr.ErrorCode = errorCode
+	}
+	apiErr.OAuthError = decoded.Error
+	apiErr.WeakPassword = decoded.WeakPassword
+	for _, m := range []string{decoded.Msg, decoded.Message, decoded.ErrorDescription} {
+		if m != "" {
+			apiErr.Message = m
+			break
+		}
+	}
+
+	return apiErr
+}
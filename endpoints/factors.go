@@ -17,10 +17,17 @@ const factorsPath = "/factors"
 
 // POST /factors
 //
-// Enroll a new factor.
-func (c *Client) EnrollFactor(req types.EnrollFactorRequest) (*types.EnrollFactorResponse, error) {
+// Enroll a new factor. FactorType defaults to phone if Phone is set, or TOTP
+// otherwise.
+func (c *Client) EnrollFactor(req types.EnrollFactorRequest) (_ *types.EnrollFactorResponse, err error) {
+	c, op := c.startOperation("EnrollFactor")
+	defer op.end(&err)
+
 	if req.FactorType == "" {
 		req.FactorType = types.FactorTypeTOTP
+		if req.Phone != "" {
+			req.FactorType = types.FactorTypePhone
+		}
 	}
 
 	body, err := json.Marshal(req)
@@ -33,18 +40,14 @@ func (c *Client) EnrollFactor(req types.EnrollFactorRequest) (*types.EnrollFacto
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.EnrollFactorResponse
@@ -56,31 +59,44 @@ func (c *Client) EnrollFactor(req types.EnrollFactorRequest) (*types.EnrollFacto
 
 // POST /factors/{factor_id}/challenge
 //
-// Challenge a factor.
-func (c *Client) ChallengeFactor(req types.ChallengeFactorRequest) (*types.ChallengeFactorResponse, error) {
+// Challenge a factor. For phone factors, this sends a code to the factor's
+// phone number over Channel.
+func (c *Client) ChallengeFactor(req types.ChallengeFactorRequest) (_ *types.ChallengeFactorResponse, err error) {
+	c, op := c.startOperation("ChallengeFactor")
+	defer op.end(&err)
+
 	url := fmt.Sprintf("%s/%s/challenge", factorsPath, req.FactorID)
-	r, err := c.newRequest(url, http.MethodPost, nil)
+
+	var body io.Reader
+	if req.Channel != "" {
+		b, err := json.Marshal(struct {
+			Channel types.MFAChannel `json:"channel"`
+		}{req.Channel})
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(b)
+	}
+
+	r, err := c.newRequest(url, http.MethodPost, body)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	type decodeResp struct {
-		ID     uuid.UUID `json:"id"`
-		Expiry int64     `json:"expires_at"`
+		ID     uuid.UUID        `json:"id"`
+		Type   types.FactorType `json:"type"`
+		Expiry int64            `json:"expires_at"`
 	}
 	res := decodeResp{}
 	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
@@ -89,6 +105,7 @@ func (c *Client) ChallengeFactor(req types.ChallengeFactorRequest) (*types.Chall
 	expiresAt := time.Unix(res.Expiry, 0)
 	return &types.ChallengeFactorResponse{
 		ID:        res.ID,
+		Type:      res.Type,
 		ExpiresAt: expiresAt,
 	}, nil
 }
@@ -96,7 +113,10 @@ func (c *Client) ChallengeFactor(req types.ChallengeFactorRequest) (*types.Chall
 // POST /factors/{factor_id}/verify
 //
 // Verify the challenge for an enrolled factor.
-func (c *Client) VerifyFactor(req types.VerifyFactorRequest) (*types.VerifyFactorResponse, error) {
+func (c *Client) VerifyFactor(req types.VerifyFactorRequest) (_ *types.VerifyFactorResponse, err error) {
+	c, op := c.startOperation("VerifyFactor")
+	defer op.end(&err)
+
 	url := fmt.Sprintf("%s/%s/verify", factorsPath, req.FactorID)
 
 	body, err := json.Marshal(req)
@@ -109,18 +129,14 @@ func (c *Client) VerifyFactor(req types.VerifyFactorRequest) (*types.VerifyFacto
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.VerifyFactorResponse
@@ -130,10 +146,78 @@ func (c *Client) VerifyFactor(req types.VerifyFactorRequest) (*types.VerifyFacto
 	return &res, nil
 }
 
+// Challenge and verify a factor in one go
+//
+// This is a convenience method that calls ChallengeFactor, then VerifyFactor
+// with the new challenge and the code. If the challenge expires before it is
+// verified, a new challenge is created and verified once more.
+//
+// It is only useful for TOTP factors, where the code doesn't depend on the
+// challenge. For phone factors, call ChallengeFactor to send the code first.
+func (c *Client) ChallengeAndVerify(factorID uuid.UUID, code string) (_ *types.VerifyFactorResponse, err error) {
+	c, op := c.startOperation("ChallengeAndVerify")
+	defer op.end(&err)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		var challenge *types.ChallengeFactorResponse
+		challenge, err = c.ChallengeFactor(types.ChallengeFactorRequest{
+			FactorID: factorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var res *types.VerifyFactorResponse
+		res, err = c.VerifyFactor(types.VerifyFactorRequest{
+			FactorID:    factorID,
+			ChallengeID: challenge.ID,
+			Code:        code,
+		})
+		if !types.HasErrorCode(err, types.ErrorCodeMFAChallengeExpired) {
+			return res, err
+		}
+	}
+	return nil, err
+}
+
+// GET /user
+//
+// List the user's factors, split into verified and unverified, and by type.
+func (c *Client) ListFactors() (_ *types.ListFactorsResponse, err error) {
+	c, op := c.startOperation("ListFactors")
+	defer op.end(&err)
+
+	user, err := c.GetUser()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &types.ListFactorsResponse{
+		All: user.Factors,
+	}
+	for _, f := range user.Factors {
+		if f.Status == types.FactorStatusVerified {
+			res.Verified = append(res.Verified, f)
+		} else {
+			res.Unverified = append(res.Unverified, f)
+		}
+		switch types.FactorType(f.FactorType) {
+		case types.FactorTypeTOTP:
+			res.TOTP = append(res.TOTP, f)
+		case types.FactorTypePhone:
+			res.Phone = append(res.Phone, f)
+		}
+	}
+	return res, nil
+}
+
 // DELETE /factors/{factor_id}
 //
 // Unenroll an enrolled factor.
-func (c *Client) UnenrollFactor(req types.UnenrollFactorRequest) (*types.UnenrollFactorResponse, error) {
+func (c *Client) UnenrollFactor(req types.UnenrollFactorRequest) (_ *types.UnenrollFactorResponse, err error) {
+	c, op := c.startOperation("UnenrollFactor")
+	defer op.end(&err)
+
 	url := fmt.Sprintf("%s/%s", factorsPath, req.FactorID)
 
 	r, err := c.newRequest(url, http.MethodDelete, nil)
@@ -141,18 +225,14 @@ func (c *Client) UnenrollFactor(req types.UnenrollFactorRequest) (*types.Unenrol
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.UnenrollFactorResponse
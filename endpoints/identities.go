@@ -0,0 +1,121 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+const identitiesPath = "/user/identities"
+
+// GET /user
+//
+// Get the identities linked to the logged in user (requires authentication).
+func (c *Client) GetUserIdentities() (_ *types.GetUserIdentitiesResponse, err error) {
+	c, op := c.startOperation("GetUserIdentities")
+	defer op.end(&err)
+
+	user, err := c.GetUser()
+	if err != nil {
+		return nil, err
+	}
+	return &types.GetUserIdentitiesResponse{
+		Identities: user.Identities,
+	}, nil
+}
+
+// GET /user/identities/authorize
+//
+// Get the URL to send the logged in user to, to link an identity from an
+// external oauth provider to their account (requires authentication). Manual
+// linking must be enabled on the server.
+//
+// Once the user has signed in with the provider, they are sent to RedirectTo
+// with a new session, as with Authorize.
+func (c *Client) LinkIdentity(req types.LinkIdentityRequest) (_ *types.LinkIdentityResponse, err error) {
+	c, op := c.startOperation("LinkIdentity")
+	defer op.end(&err)
+
+	if req.Provider == "" {
+		return nil, types.ErrInvalidLinkIdentityRequest
+	}
+
+	r, err := c.newRequest(identitiesPath+"/authorize", http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := r.URL.Query()
+	verifier, err := setAuthorizeParams(q, types.AuthorizeRequest{
+		Provider:    req.Provider,
+		FlowType:    req.FlowType,
+		Scopes:      req.Scopes,
+		RedirectTo:  req.RedirectTo,
+		QueryParams: req.QueryParams,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Ask for the provider URL in the response body rather than a redirect.
+	q.Set("skip_http_redirect", "true")
+	r.URL.RawQuery = q.Encode()
+
+	resp, err := c.do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, handleErrorResponse(resp)
+	}
+
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.URL == "" {
+		return nil, fmt.Errorf("no redirect URL found in response")
+	}
+
+	return &types.LinkIdentityResponse{
+		AuthorizationURL: body.URL,
+		Verifier:         verifier,
+	}, nil
+}
+
+// DELETE /user/identities/{identity_id}
+//
+// Unlink an identity from the logged in user (requires authentication).
+// identityID is the identity's IdentityID, not its ID. Manual linking must be
+// enabled on the server.
+//
+// A user's last identity can't be unlinked; use types.IsIdentityNotDeletable
+// to check for this error.
+func (c *Client) UnlinkIdentity(identityID uuid.UUID) (err error) {
+	c, op := c.startOperation("UnlinkIdentity")
+	defer op.end(&err)
+
+	r, err := c.newRequest(fmt.Sprintf("%s/%s", identitiesPath, identityID), http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return handleErrorResponse(resp)
+	}
+
+	return nil
+}
@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -16,7 +14,10 @@ const invitePath = "/invite"
 //
 // Invites a new user with an email.
 // This endpoint requires the service_role or supabase_admin JWT set using WithToken.
-func (c *Client) Invite(req types.InviteRequest) (*types.InviteResponse, error) {
+func (c *Client) Invite(req types.InviteRequest) (_ *types.InviteResponse, err error) {
+	c, op := c.startOperation("Invite")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -27,18 +28,14 @@ func (c *Client) Invite(req types.InviteRequest) (*types.InviteResponse, error)
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.InviteResponse
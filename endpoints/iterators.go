@@ -0,0 +1,52 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/supabase-community/gotrue-go/pagination"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+const defaultPerPage = 50
+
+// Iterate over all audit log entries matching the request, starting at
+// req.Page. Pages of req.PerPage entries (50 by default) are fetched lazily.
+func (c *Client) AdminAuditAll(ctx context.Context, req types.AdminAuditRequest, opts pagination.Options) *pagination.Iterator[types.AuditLogEntry] {
+	if req.PerPage == 0 {
+		req.PerPage = defaultPerPage
+	}
+	return pagination.NewIterator(ctx, req.Page, opts, func(ctx context.Context, page uint) (*pagination.Page[types.AuditLogEntry], error) {
+		pageReq := req
+		pageReq.Page = page
+		res, err := c.WithContext(ctx).AdminAudit(pageReq)
+		if err != nil {
+			return nil, err
+		}
+		return &pagination.Page[types.AuditLogEntry]{
+			Items:      res.Logs,
+			NextPage:   res.NextPage,
+			TotalPages: res.TotalPages,
+		}, nil
+	})
+}
+
+// Iterate over all users matching the request, starting at req.Page. Pages of
+// req.PerPage users (50 by default) are fetched lazily.
+func (c *Client) AdminListUsersAll(ctx context.Context, req types.AdminListUsersRequest, opts pagination.Options) *pagination.Iterator[types.User] {
+	if req.PerPage == 0 {
+		req.PerPage = defaultPerPage
+	}
+	return pagination.NewIterator(ctx, req.Page, opts, func(ctx context.Context, page uint) (*pagination.Page[types.User], error) {
+		pageReq := req
+		pageReq.Page = page
+		res, err := c.WithContext(ctx).AdminListUsers(pageReq)
+		if err != nil {
+			return nil, err
+		}
+		return &pagination.Page[types.User]{
+			Items:      res.Users,
+			NextPage:   res.NextPage,
+			TotalPages: res.TotalPages,
+		}, nil
+	})
+}
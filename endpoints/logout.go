@@ -1,9 +1,9 @@
 package endpoints
 
 import (
-	"fmt"
-	"io"
 	"net/http"
+
+	"github.com/supabase-community/gotrue-go/types"
 )
 
 const logoutPath = "/logout"
@@ -14,24 +14,65 @@ const logoutPath = "/logout"
 //
 // This will revoke all refresh tokens for the user. Remember that the JWT
 // tokens will still be valid for stateless auth until they expires.
-func (c *Client) Logout() error {
+func (c *Client) Logout() (err error) {
+	c, op := c.startOperation("Logout")
+	defer op.end(&err)
+
+	return c.logout(c.token, "")
+}
+
+// POST /logout?scope={scope}
+//
+// Logout a user (Requires authentication), revoking the sessions selected by
+// scope: all of the user's sessions, only the current one, or all but the
+// current one.
+func (c *Client) LogoutWithScope(scope types.LogoutScope) (err error) {
+	c, op := c.startOperation("LogoutWithScope")
+	defer op.end(&err)
+
+	return c.logout(c.token, scope)
+}
+
+// POST /logout?scope={scope}
+//
+// Sign out the user that the access token belongs to, revoking the sessions
+// selected by the scope. The request is authenticated with the access token
+// instead of the client's token, so a server can sign out any user whose
+// access token it holds.
+//
+// GoTrue has no endpoint to sign out a user by their ID.
+func (c *Client) AdminSignOut(req types.AdminSignOutRequest) (err error) {
+	c, op := c.startOperation("AdminSignOut")
+	defer op.end(&err)
+
+	if req.AccessToken == "" {
+		return types.ErrInvalidAdminSignOutRequest
+	}
+	return c.logout(req.AccessToken, req.Scope)
+}
+
+func (c *Client) logout(token string, scope types.LogoutScope) error {
 	r, err := c.newRequest(logoutPath, http.MethodPost, nil)
 	if err != nil {
 		return err
 	}
+	if token != c.token {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	if scope != "" {
+		q := r.URL.Query()
+		q.Set("scope", string(scope))
+		r.URL.RawQuery = q.Encode()
+	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return handleErrorResponse(resp)
 	}
 
 	return nil
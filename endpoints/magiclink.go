@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -21,7 +19,10 @@ const magiclinkPath = "/magiclink"
 // address which they can use to redeem an access_token.
 //
 // By default Magic Links can only be sent once every 60 seconds.
-func (c *Client) Magiclink(req types.MagiclinkRequest) error {
+func (c *Client) Magiclink(req types.MagiclinkRequest) (err error) {
+	c, op := c.startOperation("Magiclink")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return err
@@ -32,18 +33,14 @@ func (c *Client) Magiclink(req types.MagiclinkRequest) error {
 		return err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return handleErrorResponse(resp)
 	}
 
 	return nil
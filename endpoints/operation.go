@@ -0,0 +1,51 @@
+package endpoints
+
+import (
+	"context"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// An operation is a call to a client method, covering every request it sends,
+// including retries.
+type operation struct {
+	ends []func(err error)
+}
+
+// Start an operation for the named client method, calling the client's
+// operation hooks. Returns a copy of the client whose context carries the
+// operation, to send the method's requests with. End the operation with the
+// method's error once it returns:
+//
+//	c, op := c.startOperation("GetUser")
+//	defer op.end(&err)
+//
+// If the method was called by another client method, e.g. GetUser by
+// ListFactors, its requests belong to the outer operation, so no new operation
+// is started.
+func (c *Client) startOperation(name string) (*Client, *operation) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	op := &operation{}
+	if _, ok := types.OperationFromContext(ctx); ok {
+		return c, op
+	}
+
+	ctx = types.ContextWithOperation(ctx, name)
+	for _, hook := range c.operationHooks {
+		var end func(err error)
+		ctx, end = hook(ctx, name)
+		op.ends = append(op.ends, end)
+	}
+	return c.WithContext(ctx), op
+}
+
+// End the operation with the method's error, if any. Hooks are ended in the
+// reverse order to which they were started.
+func (op *operation) end(err *error) {
+	for i := len(op.ends) - 1; i >= 0; i-- {
+		op.ends[i](*err)
+	}
+}
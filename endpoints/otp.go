@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -18,7 +16,10 @@ const otpPath = "/otp"
 //
 // If CreateUser is true, the user will be automatically signed up if the user
 // doesn't exist.
-func (c *Client) OTP(req types.OTPRequest) error {
+func (c *Client) OTP(req types.OTPRequest) (err error) {
+	c, op := c.startOperation("OTP")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return err
@@ -29,18 +30,14 @@ func (c *Client) OTP(req types.OTPRequest) error {
 		return err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return handleErrorResponse(resp)
 	}
 
 	return nil
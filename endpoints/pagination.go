@@ -0,0 +1,67 @@
+package endpoints
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/tomnomnom/linkheader"
+)
+
+type pageInfo struct {
+	totalCount int
+	totalPages uint
+	nextPage   uint
+}
+
+// Parse the pagination headers returned by paginated admin endpoints.
+//
+// page is the page that was requested. It is used as the total number of
+// pages if the response does not say otherwise.
+func parsePagination(resp *http.Response, page uint) pageInfo {
+	// Result count should be given in X-Total-Count header.
+	count := resp.Header.Get("X-Total-Count")
+	resultCount := 0
+	if count != "" {
+		resultCount, _ = strconv.Atoi(count)
+	}
+
+	// Parse Link header from response to get total pages
+	links := linkheader.Parse(resp.Header.Get("Link"))
+
+	// Header should only contain one 'last' link
+	var totPages uint = page
+	l := links.FilterByRel("last")
+	if len(l) == 1 {
+		// Parse it's URL as a URL to get the query params
+		lastURL, err := url.Parse(l[0].URL)
+		if err == nil {
+			// Look for the ?page=X query param
+			last := lastURL.Query().Get("page")
+			lastPage, err := strconv.Atoi(last)
+			if err == nil {
+				totPages = uint(lastPage)
+			}
+		}
+	}
+
+	// Header may contain one 'next' link
+	var nextPage uint = 0
+	n := links.FilterByRel("next")
+	if len(n) == 1 {
+		nextURL, err := url.Parse(n[0].URL)
+		if err == nil {
+			next := nextURL.Query().Get("page")
+			nPage, err := strconv.Atoi(next)
+			if err == nil {
+				nextPage = uint(nPage)
+			}
+		}
+	}
+
+	return pageInfo{
+		totalCount: resultCount,
+		totalPages: totPages,
+		nextPage:   nextPage,
+	}
+}
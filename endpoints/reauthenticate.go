@@ -1,8 +1,6 @@
 package endpoints
 
 import (
-	"fmt"
-	"io"
 	"net/http"
 )
 
@@ -13,24 +11,25 @@ const reauthenticatePath = "/reauthenticate"
 // Sends a nonce to the user's email (preferred) or phone. This endpoint
 // requires the user to be logged in / authenticated first. The user needs to
 // have either an email or phone number for the nonce to be sent successfully.
-func (c *Client) Reauthenticate() error {
+func (c *Client) Reauthenticate() (err error) {
+	c, op := c.startOperation("Reauthenticate")
+	defer op.end(&err)
+
 	r, err := c.newRequest(reauthenticatePath, http.MethodGet, nil)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.client.Do(r)
+	// Although a GET, each request sends a new nonce, so it is not retried
+	// unless the policy opts in to retrying all requests.
+	resp, err := c.doWith(c.client, r, false)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return handleErrorResponse(resp)
 	}
 
 	return nil
@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -18,7 +16,10 @@ const recoverPath = "/recover"
 // on email address.
 //
 // By default recovery links can only be sent once every 60 seconds.
-func (c *Client) Recover(req types.RecoverRequest) error {
+func (c *Client) Recover(req types.RecoverRequest) (err error) {
+	c, op := c.startOperation("Recover")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return err
@@ -29,18 +30,14 @@ func (c *Client) Recover(req types.RecoverRequest) error {
 		return err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return handleErrorResponse(resp)
 	}
 
 	return nil
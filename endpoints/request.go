@@ -1,12 +1,17 @@
 package endpoints
 
 import (
+	"context"
 	"io"
 	"net/http"
 )
 
 func (c *Client) newRequest(path string, method string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(method, c.baseURL+path, body)
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
 		return nil, err
 	}
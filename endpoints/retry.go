@@ -0,0 +1,168 @@
+package endpoints
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+const (
+	defaultInitialBackoff = 200 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+)
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Send the request using the client's HTTP client, retrying according to the
+// client's retry policy. Requests are only retried if their method is safe to
+// repeat, unless the policy opts in to retrying all requests.
+func (c *Client) do(r *http.Request) (*http.Response, error) {
+	return c.doWith(c.client, r, isIdempotent(r.Method))
+}
+
+// Send the request using httpClient, retrying according to the client's retry
+// policy if idempotent is true or the policy opts in to retrying all requests.
+func (c *Client) doWith(httpClient http.Client, r *http.Request, idempotent bool) (*http.Response, error) {
+	httpClient = c.wrapTransport(httpClient)
+
+	policy := c.retryPolicy
+	attempts := policy.MaxAttempts
+	if attempts < 1 || !(idempotent || policy.RetryNonIdempotent) {
+		attempts = 1
+	}
+	// Requests with a body can only be retried if the body can be replayed.
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+		attempts = 1
+	}
+
+	req := r
+	for attempt := 1; ; attempt++ {
+		resp, err := httpClient.Do(req)
+		if attempt >= attempts || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		wait := backoff(policy, attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp); ok {
+				if retryAfter > maxBackoff(policy) {
+					// The server wants us to wait longer than we're willing
+					// to, so let the caller handle the response.
+					return resp, nil
+				}
+				if retryAfter > wait {
+					wait = retryAfter
+				}
+			}
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, r.Context().Err()
+		case <-timer.C:
+		}
+
+		req = r.Clone(r.Context())
+		if r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func shouldRetry(r *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// Don't retry if the caller gave up.
+		return r.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// Get the wait before the given retry, with "equal jitter": half of the
+// exponential backoff, plus a random amount up to the other half.
+func backoff(policy types.RetryPolicy, attempt int) time.Duration {
+	initial := policy.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	max := maxBackoff(policy)
+
+	d := initial
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	half := d / 2
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return half + time.Duration(jitterRand.Int63n(int64(d-half)+1))
+}
+
+func maxBackoff(policy types.RetryPolicy) time.Duration {
+	if policy.MaxBackoff <= 0 {
+		return defaultMaxBackoff
+	}
+	return policy.MaxBackoff
+}
+
+// Parse the Retry-After header of 429 and 503 responses, which may be either
+// a number of seconds or an HTTP date.
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -1,7 +1,6 @@
 package endpoints
 
 import (
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -16,24 +15,23 @@ const samlACSPath = "/sso/saml/acs"
 //
 // If successful, the server returns an XML response. Making sense of this is
 // outside the scope of this client, so it is simply returned as []byte.
-func (c *Client) SAMLMetadata() ([]byte, error) {
+func (c *Client) SAMLMetadata() (_ []byte, err error) {
+	c, op := c.startOperation("SAMLMetadata")
+	defer op.end(&err)
+
 	r, err := c.newRequest(samlMetadataPath, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	return io.ReadAll(resp.Body)
@@ -62,12 +60,16 @@ func (c *Client) SAMLMetadata() ([]byte, error) {
 //			return http.ErrUseLastResponse
 //		},
 //	}
-func (c *Client) SAMLACS(req *http.Request) (*http.Response, error) {
+func (c *Client) SAMLACS(req *http.Request) (_ *http.Response, err error) {
+	c, op := c.WithContext(req.Context()).startOperation("SAMLACS")
+	defer op.end(&err)
+
+	req = req.WithContext(c.ctx)
 	acsURL := c.baseURL + samlACSPath
 	u, err := url.Parse(acsURL)
 	if err != nil {
 		return nil, err
 	}
 	req.URL = u
-	return c.client.Do(req)
+	return c.do(req)
 }
@@ -2,8 +2,6 @@ package endpoints
 
 import (
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -14,24 +12,23 @@ var settingsPath = "/settings"
 // GET /settings
 //
 // Returns the publicly available settings for this gotrue instance.
-func (c *Client) GetSettings() (*types.SettingsResponse, error) {
+func (c *Client) GetSettings() (_ *types.SettingsResponse, err error) {
+	c, op := c.startOperation("GetSettings")
+	defer op.end(&err)
+
 	r, err := c.newRequest(settingsPath, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.SettingsResponse
@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -17,7 +15,10 @@ const signupPath = "/signup"
 // POST /signup
 //
 // Register a new user with an email and password.
-func (c *Client) Signup(req types.SignupRequest) (*types.SignupResponse, error) {
+func (c *Client) Signup(req types.SignupRequest) (_ *types.SignupResponse, err error) {
+	c, op := c.startOperation("Signup")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -28,18 +29,14 @@ func (c *Client) Signup(req types.SignupRequest) (*types.SignupResponse, error)
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.SignupResponse
@@ -59,3 +56,42 @@ func (c *Client) Signup(req types.SignupRequest) (*types.SignupResponse, error)
 
 	return &res, nil
 }
+
+// POST /signup
+//
+// Create a new anonymous user and sign them in. Anonymous sign-ins must be
+// enabled on the server.
+//
+// The anonymous user can later be converted into a permanent user by adding
+// an email or phone, and a password, with UpdateUser.
+func (c *Client) SignInAnonymously(req types.SignInAnonymouslyRequest) (_ *types.SignInAnonymouslyResponse, err error) {
+	c, op := c.startOperation("SignInAnonymously")
+	defer op.end(&err)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.newRequest(signupPath, http.MethodPost, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, handleErrorResponse(resp)
+	}
+
+	var res types.SignInAnonymouslyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -22,7 +20,10 @@ const ssoPath = "/sso"
 // GoTrue allows you to skip following the redirect by setting SkipHTTPRedirect
 // on the request struct. In this case, the URL to redirect to will be returned
 // in the response.
-func (c *Client) SSO(req types.SSORequest) (*types.SSOResponse, error) {
+func (c *Client) SSO(req types.SSORequest) (_ *types.SSOResponse, err error) {
+	c, op := c.startOperation("SSO")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -33,7 +34,7 @@ func (c *Client) SSO(req types.SSORequest) (*types.SSOResponse, error) {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
@@ -48,11 +49,7 @@ func (c *Client) SSO(req types.SSORequest) (*types.SSOResponse, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusSeeOther {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	// If the client is not following redirects, we can unmarshal the response from
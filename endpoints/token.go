@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -44,11 +42,42 @@ func (c *Client) RefreshToken(refreshToken string) (*types.TokenResponse, error)
 	})
 }
 
+// Exchange an auth code for a session
+//
+// This is a convenience method that calls Token with the pkce grant type. code
+// is the code the user was redirected back with, and codeVerifier is the
+// Verifier of the PKCE params used to start the flow.
+func (c *Client) ExchangeCodeForSession(code, codeVerifier string) (*types.TokenResponse, error) {
+	return c.Token(types.TokenRequest{
+		GrantType:    "pkce",
+		Code:         code,
+		CodeVerifier: codeVerifier,
+	})
+}
+
+// Sign in with an ID token
+//
+// This is a convenience method that calls Token with the id_token grant type.
+// Use it to exchange an ID token obtained with a provider's native sign-in
+// SDK, e.g. Sign in with Apple on iOS, for a session. nonce may be empty if no
+// nonce was used.
+func (c *Client) SignInWithIDToken(provider types.Provider, idToken, nonce string) (*types.TokenResponse, error) {
+	return c.Token(types.TokenRequest{
+		GrantType: "id_token",
+		Provider:  provider,
+		IDToken:   idToken,
+		Nonce:     nonce,
+	})
+}
+
 // POST /token
 //
 // This is an OAuth2 endpoint that currently implements the password,
-// refresh_token, and PKCE grant types
-func (c *Client) Token(req types.TokenRequest) (*types.TokenResponse, error) {
+// refresh_token, PKCE and id_token grant types
+func (c *Client) Token(req types.TokenRequest) (_ *types.TokenResponse, err error) {
+	c, op := c.startOperation("Token")
+	defer op.end(&err)
+
 	switch req.GrantType {
 	case "password":
 		if (req.Email == "" && req.Phone == "") || req.Password == "" || req.RefreshToken != "" {
@@ -62,6 +91,10 @@ func (c *Client) Token(req types.TokenRequest) (*types.TokenResponse, error) {
 		if req.Code == "" || req.CodeVerifier == "" {
 			return nil, types.ErrInvalidTokenRequest
 		}
+	case "id_token":
+		if req.Provider == "" || req.IDToken == "" || req.Email != "" || req.Phone != "" || req.Password != "" || req.RefreshToken != "" {
+			return nil, types.ErrInvalidTokenRequest
+		}
 	default:
 		return nil, types.ErrInvalidTokenRequest
 	}
@@ -75,18 +108,14 @@ func (c *Client) Token(req types.TokenRequest) (*types.TokenResponse, error) {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.TokenResponse
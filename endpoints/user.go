@@ -3,8 +3,6 @@ package endpoints
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/supabase-community/gotrue-go/types"
@@ -15,24 +13,23 @@ var userPath = "/user"
 // GET /user
 //
 // Get the JSON object for the logged in user (requires authentication)
-func (c *Client) GetUser() (*types.UserResponse, error) {
+func (c *Client) GetUser() (_ *types.UserResponse, err error) {
+	c, op := c.startOperation("GetUser")
+	defer op.end(&err)
+
 	r, err := c.newRequest(userPath, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.UserResponse
@@ -49,7 +46,10 @@ func (c *Client) GetUser() (*types.UserResponse, error) {
 // Update a user (Requires authentication). Apart from changing email/password,
 // this method can be used to set custom user data. Changing the email will
 // result in a magiclink being sent out.
-func (c *Client) UpdateUser(req types.UpdateUserRequest) (*types.UpdateUserResponse, error) {
+func (c *Client) UpdateUser(req types.UpdateUserRequest) (_ *types.UpdateUserResponse, err error) {
+	c, op := c.startOperation("UpdateUser")
+	defer op.end(&err)
+
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -60,18 +60,14 @@ func (c *Client) UpdateUser(req types.UpdateUserRequest) (*types.UpdateUserRespo
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.UpdateUserResponse
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -22,12 +21,16 @@ const verifyPath = "/verify"
 //
 // The server returns a redirect response. This method will not follow the
 // redirect, but instead returns the URL the client was told to redirect to,
-// as well as parsing the parameters from the URL fragment.
+// as well as parsing the parameters from the URL fragment, or the code from
+// the query if the PKCE flow was used.
 //
 // NOTE: This endpoint may return a nil error, but the Response can contain
 // error details extracted from the returned URL. Please check that the Error,
 // ErrorCode and/or ErrorDescription fields of the response are empty.
-func (c *Client) Verify(req types.VerifyRequest) (*types.VerifyResponse, error) {
+func (c *Client) Verify(req types.VerifyRequest) (_ *types.VerifyResponse, err error) {
+	c, op := c.startOperation("Verify")
+	defer op.end(&err)
+
 	if req.Type == "" {
 		return nil, types.ErrInvalidVerifyRequest
 	}
@@ -52,18 +55,16 @@ func (c *Client) Verify(req types.VerifyRequest) (*types.VerifyResponse, error)
 	// Set up a client that will not follow the redirect.
 	noRedirClient := noRedirClient(c.client)
 
-	resp, err := noRedirClient.Do(r)
+	// Verification tokens can only be used once, so the request is not safe
+	// to retry.
+	resp, err := c.doWith(noRedirClient, r, false)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusSeeOther {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	redirURL := resp.Header.Get("Location")
@@ -86,7 +87,8 @@ func (c *Client) Verify(req types.VerifyRequest) (*types.VerifyResponse, error)
 	}
 
 	return &types.VerifyResponse{
-		URL: redirURL,
+		URL:  redirURL,
+		Code: u.Query().Get("code"),
 
 		AccessToken:  values.Get("access_token"),
 		TokenType:    values.Get("token_type"),
@@ -109,7 +111,10 @@ func (c *Client) Verify(req types.VerifyRequest) (*types.VerifyResponse, error)
 // This differs from GET /verify as it requires an email or phone to be given,
 // which is used to verify the token associated to the user. It also returns a
 // JSON response rather than a redirect.
-func (c *Client) VerifyForUser(req types.VerifyForUserRequest) (*types.VerifyForUserResponse, error) {
+func (c *Client) VerifyForUser(req types.VerifyForUserRequest) (_ *types.VerifyForUserResponse, err error) {
+	c, op := c.startOperation("VerifyForUser")
+	defer op.end(&err)
+
 	if req.Type == "" {
 		return nil, types.ErrInvalidVerifyRequest
 	}
@@ -133,18 +138,14 @@ func (c *Client) VerifyForUser(req types.VerifyForUserRequest) (*types.VerifyFor
 		return nil, err
 	}
 
-	resp, err := c.client.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusSeeOther {
-		fullBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("response status code %d", resp.StatusCode)
-		}
-		return nil, fmt.Errorf("response status code %d: %s", resp.StatusCode, fullBody)
+		return nil, handleErrorResponse(resp)
 	}
 
 	var res types.VerifyForUserResponse
@@ -0,0 +1,60 @@
+package gotrue_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestErrorResponse(t *testing.T) {
+	tests := map[string]struct {
+		body      string
+		code      int
+		errorCode types.ErrorCode
+		message   string
+	}{
+		"numeric code": {
+			body:      `{"code":422,"error_code":"weak_password","msg":"Password is too weak"}`,
+			code:      422,
+			errorCode: types.ErrorCodeWeakPassword,
+			message:   "Password is too weak",
+		},
+		"string code": {
+			body:      `{"code":"weak_password","message":"Password is too weak"}`,
+			errorCode: types.ErrorCodeWeakPassword,
+			message:   "Password is too weak",
+		},
+		"string code and error_code": {
+			body:      `{"code":"unexpected","error_code":"weak_password","msg":"Password is too weak"}`,
+			errorCode: types.ErrorCodeWeakPassword,
+			message:   "Password is too weak",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(test.body))
+			}))
+			defer srv.Close()
+
+			_, err := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).GetSettings()
+			var apiErr *types.APIError
+			require.True(errors.As(err, &apiErr))
+			assert.Equal(http.StatusUnprocessableEntity, apiErr.StatusCode)
+			assert.Equal(test.code, apiErr.Code)
+			assert.Equal(test.errorCode, apiErr.ErrorCode)
+			assert.Equal(test.message, apiErr.Message)
+		})
+	}
+}
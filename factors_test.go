@@ -0,0 +1,151 @@
+package gotrue_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// Start a server whose first expired challenges have expired by the time they
+// are verified.
+func newFactorServer(t *testing.T, expired int) *testServer {
+	challenges := 0
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/challenge"):
+			challenges++
+			fmt.Fprintf(w, `{"id":"%s","type":"totp","expires_at":%d}`, uuid.New(), time.Now().Add(time.Minute).Unix())
+		case strings.HasSuffix(r.URL.Path, "/verify"):
+			var body struct {
+				Code string `json:"code"`
+			}
+			if !decodeBody(t, w, r, &body) {
+				return
+			}
+			switch {
+			case challenges <= expired:
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprint(w, `{"code":422,"error_code":"mfa_challenge_expired","msg":"MFA challenge has expired"}`)
+			case body.Code != "123456":
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprint(w, `{"code":422,"error_code":"mfa_verification_failed","msg":"Invalid TOTP code entered"}`)
+			default:
+				fmt.Fprint(w, `{"access_token":"aal2","token_type":"bearer","expires_in":3600}`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestChallengeAndVerify(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	factorID := uuid.New()
+
+	srv := newFactorServer(t, 0)
+	client := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithToken("aal1")
+	session, err := client.ChallengeAndVerify(factorID, "123456")
+	require.NoError(err)
+	assert.Equal("aal2", session.AccessToken)
+	assert.Equal([]string{"challenge", "verify"}, srv.calls())
+
+	// Wrong codes are not retried.
+	srv.resetCalls()
+	_, err = client.ChallengeAndVerify(factorID, "000000")
+	assert.Error(err)
+	assert.Equal([]string{"challenge", "verify"}, srv.calls())
+
+	// Expired challenges are retried once.
+	srv = newFactorServer(t, 1)
+	client = gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithToken("aal1")
+	session, err = client.ChallengeAndVerify(factorID, "123456")
+	require.NoError(err)
+	assert.Equal("aal2", session.AccessToken)
+	assert.Equal([]string{"challenge", "verify", "challenge", "verify"}, srv.calls())
+
+	srv = newFactorServer(t, 2)
+	client = gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithToken("aal1")
+	_, err = client.ChallengeAndVerify(factorID, "123456")
+	assert.True(types.HasErrorCode(err, types.ErrorCodeMFAChallengeExpired))
+	assert.Len(srv.calls(), 4)
+}
+
+func TestAdminDeleteUserFactors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	userID := uuid.New()
+	verifiedTOTP := types.Factor{ID: uuid.New(), Status: types.FactorStatusVerified, FactorType: "totp"}
+	unverifiedTOTP := types.Factor{ID: uuid.New(), Status: types.FactorStatusUnverified, FactorType: "totp"}
+	verifiedPhone := types.Factor{ID: uuid.New(), Status: types.FactorStatusVerified, FactorType: "phone"}
+
+	var factors []types.Factor
+	var failID uuid.UUID
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if err := json.NewEncoder(w).Encode(factors); err != nil {
+				t.Errorf("failed to encode factors: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		id := uuid.MustParse(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		if id == failID {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"code":500,"msg":"Database error deleting factor"}`)
+			return
+		}
+		for i, f := range factors {
+			if f.ID == id {
+				factors = append(factors[:i], factors[i+1:]...)
+				fmt.Fprint(w, `{}`)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":404,"error_code":"mfa_factor_not_found","msg":"Factor not found"}`)
+	})
+	client := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithToken("admin")
+
+	// Filter by type and status
+	factors = []types.Factor{verifiedTOTP, unverifiedTOTP, verifiedPhone}
+	res, err := client.AdminDeleteUserFactors(types.AdminDeleteUserFactorsRequest{
+		UserID:      userID,
+		FactorTypes: []types.FactorType{types.FactorTypeTOTP},
+		Statuses:    []string{types.FactorStatusVerified},
+	})
+	require.NoError(err)
+	assert.Equal(userID, res.UserID)
+	assert.Equal([]types.Factor{verifiedTOTP}, res.Deleted)
+	assert.Equal([]types.Factor{unverifiedTOTP, verifiedPhone}, factors)
+
+	// Everything
+	res, err = client.AdminDeleteUserFactors(types.AdminDeleteUserFactorsRequest{
+		UserID: userID,
+	})
+	require.NoError(err)
+	assert.Equal([]types.Factor{unverifiedTOTP, verifiedPhone}, res.Deleted)
+	assert.Empty(factors)
+
+	// A failed deletion returns the factors deleted so far.
+	factors = []types.Factor{verifiedTOTP, unverifiedTOTP, verifiedPhone}
+	failID = unverifiedTOTP.ID
+	res, err = client.AdminDeleteUserFactors(types.AdminDeleteUserFactorsRequest{
+		UserID: userID,
+	})
+	assert.Error(err)
+	require.NotNil(res)
+	assert.Equal([]types.Factor{verifiedTOTP}, res.Deleted)
+}
@@ -1,11 +1,13 @@
 package integration_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/supabase-community/gotrue-go/pagination"
 	"github.com/supabase-community/gotrue-go/types"
 )
 
@@ -112,3 +114,46 @@ func TestAdminAudit(t *testing.T) {
 	require.NoError(err)
 	assert.Len(resp.Logs, 0)
 }
+
+func TestAdminAuditAll(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	admin := withAdmin(client)
+
+	// Make sure there are a few pages of audit logs.
+	for i := 0; i < 6; i++ {
+		_, err := client.Signup(types.SignupRequest{
+			Email:    randomEmail(),
+			Password: "password",
+		})
+		require.NoError(err)
+	}
+
+	first, err := admin.AdminAudit(types.AdminAuditRequest{Page: 1, PerPage: 2})
+	require.NoError(err)
+
+	it := admin.AdminAuditAll(context.Background(), types.AdminAuditRequest{
+		PerPage: 2,
+	}, pagination.Options{
+		Prefetch: 2,
+	})
+	defer it.Close()
+
+	// Other tests may add logs while iterating, so only check that at least
+	// as many logs as there were at the start are returned.
+	count := 0
+	for it.Next() {
+		assert.NotEmpty(it.Value().ID)
+		count++
+	}
+	require.NoError(it.Err())
+	assert.GreaterOrEqual(count, first.TotalCount)
+
+	// Cancelled context stops iteration
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	it = admin.AdminAuditAll(ctx, types.AdminAuditRequest{}, pagination.Options{})
+	assert.False(it.Next())
+	assert.ErrorIs(it.Err(), context.Canceled)
+}
@@ -1,12 +1,14 @@
 package integration_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/supabase-community/gotrue-go/pagination"
 	"github.com/supabase-community/gotrue-go/types"
 )
 
@@ -52,7 +54,7 @@ func TestAdminListUsers(t *testing.T) {
 	require.Regexp(uuidRegex, createResp.ID)
 
 	// Then list and look up the user we just created
-	resp, err := admin.AdminListUsers()
+	resp, err := admin.AdminListUsers(types.AdminListUsersRequest{})
 	require.NoError(err)
 	assert.NotEmpty(resp)
 	for _, u := range resp.Users {
@@ -61,6 +63,83 @@ func TestAdminListUsers(t *testing.T) {
 			assert.Equal(u.Email, createResp.Email)
 		}
 	}
+
+	// Filter by email
+	resp, err = admin.AdminListUsers(types.AdminListUsersRequest{
+		Filter: email,
+	})
+	require.NoError(err)
+	require.Len(resp.Users, 1)
+	assert.Equal(createResp.ID, resp.Users[0].ID)
+	assert.Equal(1, resp.TotalCount)
+	assert.EqualValues(0, resp.NextPage)
+
+	// Paginate and sort, newest first
+	for i := 0; i < 3; i++ {
+		_, err := admin.AdminCreateUser(types.AdminCreateUserRequest{
+			Email:    randomEmail(),
+			Password: &pass,
+		})
+		require.NoError(err)
+	}
+	resp, err = admin.AdminListUsers(types.AdminListUsersRequest{
+		Sort: &types.UserSort{
+			Column:    "created_at",
+			Direction: types.SortDescending,
+		},
+		Page:    1,
+		PerPage: 2,
+	})
+	require.NoError(err)
+	require.Len(resp.Users, 2)
+	assert.GreaterOrEqual(resp.TotalCount, 4)
+	assert.EqualValues(2, resp.NextPage)
+	assert.GreaterOrEqual(resp.TotalPages, uint(2))
+	assert.False(resp.Users[0].CreatedAt.Before(resp.Users[1].CreatedAt))
+
+	resp, err = admin.AdminListUsers(types.AdminListUsersRequest{
+		Page:    2,
+		PerPage: 2,
+	})
+	require.NoError(err)
+	assert.Len(resp.Users, 2)
+
+	// Invalid sort
+	_, err = admin.AdminListUsers(types.AdminListUsersRequest{
+		Sort: &types.UserSort{Column: "created_at", Direction: "sideways"},
+	})
+	assert.ErrorIs(err, types.ErrInvalidAdminListUsersRequest)
+}
+
+func TestAdminListUsersAll(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	admin := withAdmin(client)
+
+	// Create some users with a common name so they can be filtered.
+	name := randomString(12)
+	created := map[uuid.UUID]bool{}
+	for i := 0; i < 5; i++ {
+		u, err := admin.AdminCreateUser(types.AdminCreateUserRequest{
+			Email:        randomEmail(),
+			UserMetadata: map[string]interface{}{"full_name": name},
+		})
+		require.NoError(err)
+		created[u.ID] = true
+	}
+
+	users, err := pagination.Collect(admin.AdminListUsersAll(context.Background(), types.AdminListUsersRequest{
+		Filter:  name,
+		PerPage: 2,
+	}, pagination.Options{
+		Prefetch: 1,
+	}))
+	require.NoError(err)
+	assert.Len(users, 5)
+	for _, u := range users {
+		assert.True(created[u.ID])
+	}
 }
 
 func TestAdminGetUser(t *testing.T) {
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/supabase-community/gotrue-go/totp"
 	"github.com/supabase-community/gotrue-go/types"
 )
 
@@ -140,3 +141,67 @@ func TestDeleteUserFactor(t *testing.T) {
 
 	// Cannot test successfully deleting a factor, as we cannot create a verified factor to delete
 }
+
+func TestDeleteUserFactors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	admin := withAdmin(client)
+
+	// Create a user with a verified TOTP factor and an unverified one.
+	session, err := autoconfirmClient.Signup(types.SignupRequest{
+		Email:    randomEmail(),
+		Password: "password",
+	})
+	require.NoError(err)
+	user := autoconfirmClient.WithToken(session.AccessToken)
+
+	verified, err := user.EnrollFactor(types.EnrollFactorRequest{
+		FactorType:   types.FactorTypeTOTP,
+		FriendlyName: "Verified",
+		Issuer:       "example.com",
+	})
+	require.NoError(err)
+	code, err := totp.GenerateCode(verified.TOTP.Secret)
+	require.NoError(err)
+	verifyResp, err := user.ChallengeAndVerify(verified.ID, code)
+	require.NoError(err)
+
+	unverified, err := autoconfirmClient.WithToken(verifyResp.AccessToken).EnrollFactor(types.EnrollFactorRequest{
+		FactorType:   types.FactorTypeTOTP,
+		FriendlyName: "Unverified",
+		Issuer:       "example.com",
+	})
+	require.NoError(err)
+
+	// Delete only verified factors
+	res, err := admin.AdminDeleteUserFactors(types.AdminDeleteUserFactorsRequest{
+		UserID:   session.User.ID,
+		Statuses: []string{types.FactorStatusVerified},
+	})
+	require.NoError(err)
+	require.Len(res.Deleted, 1)
+	assert.Equal(verified.ID, res.Deleted[0].ID)
+
+	factors, err := admin.AdminListUserFactors(types.AdminListUserFactorsRequest{
+		UserID: session.User.ID,
+	})
+	require.NoError(err)
+	require.Len(factors.Factors, 1)
+	assert.Equal(unverified.ID, factors.Factors[0].ID)
+
+	// Delete the rest
+	res, err = admin.AdminDeleteUserFactors(types.AdminDeleteUserFactorsRequest{
+		UserID: session.User.ID,
+	})
+	require.NoError(err)
+	require.Len(res.Deleted, 1)
+	assert.Equal(unverified.ID, res.Deleted[0].ID)
+
+	// Nothing left to delete
+	res, err = admin.AdminDeleteUserFactors(types.AdminDeleteUserFactorsRequest{
+		UserID: session.User.ID,
+	})
+	require.NoError(err)
+	assert.Empty(res.Deleted)
+}
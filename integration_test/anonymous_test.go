@@ -0,0 +1,64 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestSignInAnonymously(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	settings, err := autoconfirmClient.GetSettings()
+	require.NoError(err)
+	assert.True(settings.External.AnonymousUsers)
+
+	session, err := autoconfirmClient.SignInAnonymously(types.SignInAnonymouslyRequest{
+		Data: map[string]interface{}{
+			"theme": "dark",
+		},
+	})
+	require.NoError(err)
+	assert.NotEmpty(session.AccessToken)
+	assert.NotEmpty(session.RefreshToken)
+	assert.True(session.User.IsAnonymous)
+	assert.Empty(session.User.Email)
+	assert.Equal("dark", session.User.UserMetadata["theme"])
+
+	// The access token claims mark the user as anonymous.
+	claims, err := session.Claims()
+	require.NoError(err)
+	assert.True(claims.IsAnonymous)
+	assert.False(claims.IsExpired(0))
+
+	// Convert to a permanent user by adding an email, then a password.
+	userClient := autoconfirmClient.WithToken(session.AccessToken)
+	email := randomEmail()
+	updated, err := userClient.UpdateUser(types.UpdateUserRequest{
+		Email: email,
+	})
+	require.NoError(err)
+	assert.Equal(session.User.ID, updated.ID)
+	assert.Equal(email, updated.Email)
+	assert.False(updated.IsAnonymous)
+
+	password := "password"
+	_, err = userClient.UpdateUser(types.UpdateUserRequest{
+		Password: &password,
+	})
+	require.NoError(err)
+
+	signIn, err := autoconfirmClient.SignInWithEmailPassword(email, password)
+	require.NoError(err)
+	assert.Equal(session.User.ID, signIn.User.ID)
+	assert.False(signIn.User.IsAnonymous)
+	assert.Equal("dark", signIn.User.UserMetadata["theme"])
+
+	// Anonymous sign-ins are disabled by default.
+	_, err = client.SignInAnonymously(types.SignInAnonymouslyRequest{})
+	assert.Error(err)
+}
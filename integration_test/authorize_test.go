@@ -1,6 +1,8 @@
 package integration_test
 
 import (
+	"net/http"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,7 +37,65 @@ func TestAuthorize(t *testing.T) {
 	require.NotEmpty(resp.AuthorizationURL)
 	require.NotEmpty(resp.Verifier)
 
+	// Redirect back to the app
+	resp, err = autoconfirmClient.Authorize(types.AuthorizeRequest{
+		Provider:   "github",
+		FlowType:   "pkce",
+		RedirectTo: "http://localhost:3000/auth/callback",
+	})
+	require.NoError(err)
+	assert.Contains(resp.AuthorizationURL, "github.com/login/oauth/authorize")
+
+	// Provider specific query params are passed on to the provider
+	resp, err = autoconfirmClient.Authorize(types.AuthorizeRequest{
+		Provider: "github",
+		QueryParams: map[string]string{
+			"prompt": "consent",
+		},
+	})
+	require.NoError(err)
+	authURL, err := url.Parse(resp.AuthorizationURL)
+	require.NoError(err)
+	assert.Equal("consent", authURL.Query().Get("prompt"))
+
+	// Build the URL locally
+	resp, err = autoconfirmClient.Authorize(types.AuthorizeRequest{
+		Provider:         "github",
+		FlowType:         "pkce",
+		Scopes:           "repo",
+		RedirectTo:       "http://localhost:3000/auth/callback",
+		SkipHTTPRedirect: true,
+		QueryParams: map[string]string{
+			"prompt":   "consent",
+			"provider": "ignored",
+		},
+	})
+	require.NoError(err)
+	require.NotEmpty(resp.Verifier)
+	authURL, err = url.Parse(resp.AuthorizationURL)
+	require.NoError(err)
+	assert.Equal("/authorize", authURL.Path)
+	q := authURL.Query()
+	assert.Equal("github", q.Get("provider"))
+	assert.Equal("repo", q.Get("scopes"))
+	assert.Equal("http://localhost:3000/auth/callback", q.Get("redirect_to"))
+	assert.Equal("S256", q.Get("code_challenge_method"))
+	assert.NotEmpty(q.Get("code_challenge"))
+	assert.Equal("consent", q.Get("prompt"))
+
+	// The locally built URL redirects to the provider
+	noRedirect := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	httpResp, err := noRedirect.Get(resp.AuthorizationURL)
+	require.NoError(err)
+	httpResp.Body.Close()
+	assert.Equal(http.StatusFound, httpResp.StatusCode)
+	assert.Contains(httpResp.Header.Get("Location"), "github.com/login/oauth/authorize")
+
 	// No provider chosen
 	_, err = autoconfirmClient.Authorize(types.AuthorizeRequest{})
-	assert.Error(err)
+	assert.ErrorIs(err, types.ErrInvalidAuthorizeRequest)
 }
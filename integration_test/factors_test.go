@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/supabase-community/gotrue-go/totp"
 	"github.com/supabase-community/gotrue-go/types"
 )
 
@@ -98,8 +99,7 @@ func TestFactors(t *testing.T) {
 	})
 	assert.Error(err)
 
-	// Cannot test verify with a valid code without actually enrolling a TOTP
-	// factor.
+	// Verifying with a valid code is tested in TestTOTPFactor.
 
 	// Delete factor with invalid request
 	_, err = client.UnenrollFactor(types.UnenrollFactorRequest{})
@@ -112,3 +112,163 @@ func TestFactors(t *testing.T) {
 	require.NoError(err)
 	assert.Equal(factorResp.ID, unenrollResp.ID)
 }
+
+func TestTOTPFactor(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	session, err := autoconfirmClient.Signup(types.SignupRequest{
+		Email:    randomEmail(),
+		Password: "password",
+	})
+	require.NoError(err)
+	client := autoconfirmClient.WithToken(session.AccessToken)
+
+	factorResp, err := client.EnrollFactor(types.EnrollFactorRequest{
+		FriendlyName: "Test TOTP",
+		FactorType:   types.FactorTypeTOTP,
+		Issuer:       "mine.com",
+	})
+	require.NoError(err)
+
+	key, err := totp.ParseURI(factorResp.TOTP.URI)
+	require.NoError(err)
+	assert.Equal("mine.com", key.Issuer)
+	assert.Equal(factorResp.TOTP.Secret, key.Secret)
+
+	_, err = totp.QRCodePNG(factorResp.TOTP.QRCode, 4)
+	assert.NoError(err)
+
+	code, err := key.Generate(time.Now())
+	require.NoError(err)
+	verifyResp, err := client.ChallengeAndVerify(factorResp.ID, code)
+	require.NoError(err)
+	assert.NotEmpty(verifyResp.AccessToken)
+
+	client = client.WithToken(verifyResp.AccessToken)
+	aal, err := client.GetAuthenticatorAssuranceLevel()
+	require.NoError(err)
+	assert.Equal(types.AAL2, aal.CurrentLevel)
+}
+
+func TestPhoneFactors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The test server is configured with a fixed OTP for this number, so no
+	// SMS is sent.
+	const phone = "15555550100"
+	const code = "123456"
+
+	session, err := autoconfirmClient.Signup(types.SignupRequest{
+		Email:    randomEmail(),
+		Password: "password",
+	})
+	require.NoError(err)
+	client := autoconfirmClient.WithToken(session.AccessToken)
+
+	// Phone is required
+	_, err = client.EnrollFactor(types.EnrollFactorRequest{
+		FactorType: types.FactorTypePhone,
+	})
+	assert.Error(err)
+
+	// Factor type defaults to phone when a phone number is given
+	factorResp, err := client.EnrollFactor(types.EnrollFactorRequest{
+		FriendlyName: "Test Phone",
+		Phone:        "+" + phone,
+	})
+	require.NoError(err)
+	assert.Equal(types.FactorTypePhone, factorResp.Type)
+	assert.NotEqual(uuid.Nil, factorResp.ID)
+	assert.Equal(phone, factorResp.Phone)
+	assert.Empty(factorResp.TOTP.Secret)
+
+	user, err := client.GetUser()
+	require.NoError(err)
+	require.Len(user.Factors, 1)
+	assert.Equal(string(types.FactorTypePhone), user.Factors[0].FactorType)
+	assert.Equal(phone, user.Factors[0].Phone)
+
+	// Requires a user token
+	_, err = autoconfirmClient.GetAuthenticatorAssuranceLevel()
+	assert.Error(err)
+
+	// Unverified factors don't raise the next level
+	aal, err := client.GetAuthenticatorAssuranceLevel()
+	require.NoError(err)
+	assert.Equal(types.AAL1, aal.CurrentLevel)
+	assert.Equal(types.AAL1, aal.NextLevel)
+	require.Len(aal.CurrentAuthenticationMethods, 1)
+	assert.Equal("password", aal.CurrentAuthenticationMethods[0].Method)
+
+	// Challenge over SMS
+	challengeResp, err := client.ChallengeFactor(types.ChallengeFactorRequest{
+		FactorID: factorResp.ID,
+		Channel:  types.MFAChannelSMS,
+	})
+	require.NoError(err)
+	assert.NotEqual(uuid.Nil, challengeResp.ID)
+	assert.Greater(challengeResp.ExpiresAt, time.Now())
+
+	// Wrong code
+	_, err = client.VerifyFactor(types.VerifyFactorRequest{
+		FactorID:    factorResp.ID,
+		ChallengeID: challengeResp.ID,
+		Code:        "000000",
+	})
+	assert.Error(err)
+
+	// Correct code
+	verifyResp, err := client.VerifyFactor(types.VerifyFactorRequest{
+		FactorID:    factorResp.ID,
+		ChallengeID: challengeResp.ID,
+		Code:        code,
+	})
+	require.NoError(err)
+	assert.NotEmpty(verifyResp.AccessToken)
+
+	user, err = autoconfirmClient.WithToken(verifyResp.AccessToken).GetUser()
+	require.NoError(err)
+	require.Len(user.Factors, 1)
+	assert.Equal("verified", user.Factors[0].Status)
+
+	// Add an unverified TOTP factor too
+	totpResp, err := client.EnrollFactor(types.EnrollFactorRequest{
+		FriendlyName: "Test TOTP",
+		FactorType:   types.FactorTypeTOTP,
+		Issuer:       "test.com",
+	})
+	require.NoError(err)
+
+	factors, err := client.ListFactors()
+	require.NoError(err)
+	assert.Len(factors.All, 2)
+	require.Len(factors.Verified, 1)
+	assert.Equal(factorResp.ID, factors.Verified[0].ID)
+	require.Len(factors.Unverified, 1)
+	assert.Equal(totpResp.ID, factors.Unverified[0].ID)
+	require.Len(factors.Phone, 1)
+	assert.Equal(factorResp.ID, factors.Phone[0].ID)
+	require.Len(factors.TOTP, 1)
+	assert.Equal(totpResp.ID, factors.TOTP[0].ID)
+
+	// The old session could be upgraded, the new one has been
+	aal, err = client.GetAuthenticatorAssuranceLevel()
+	require.NoError(err)
+	assert.Equal(types.AAL1, aal.CurrentLevel)
+	assert.Equal(types.AAL2, aal.NextLevel)
+
+	aal, err = autoconfirmClient.WithToken(verifyResp.AccessToken).GetAuthenticatorAssuranceLevel()
+	require.NoError(err)
+	assert.Equal(types.AAL2, aal.CurrentLevel)
+	assert.Equal(types.AAL2, aal.NextLevel)
+	assert.Len(aal.CurrentAuthenticationMethods, 2)
+
+	// Unknown channel
+	_, err = client.ChallengeFactor(types.ChallengeFactorRequest{
+		FactorID: factorResp.ID,
+		Channel:  "pigeon",
+	})
+	assert.Error(err)
+}
@@ -0,0 +1,74 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestIdentities(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// No user token
+	_, err := autoconfirmClient.GetUserIdentities()
+	assert.Error(err)
+	_, err = autoconfirmClient.LinkIdentity(types.LinkIdentityRequest{
+		Provider: types.ProviderGitHub,
+	})
+	assert.Error(err)
+
+	session, err := autoconfirmClient.Signup(types.SignupRequest{
+		Email:    randomEmail(),
+		Password: "password",
+	})
+	require.NoError(err)
+	client := autoconfirmClient.WithToken(session.AccessToken)
+
+	// Email users have an email identity.
+	identities, err := client.GetUserIdentities()
+	require.NoError(err)
+	require.Len(identities.Identities, 1)
+	assert.Equal("email", identities.Identities[0].Provider)
+	assert.NotEqual(uuid.Nil, identities.Identities[0].IdentityID)
+
+	// The only identity cannot be unlinked.
+	err = client.UnlinkIdentity(identities.Identities[0].IdentityID)
+	assert.True(types.IsIdentityNotDeletable(err))
+
+	// Unknown identity
+	err = client.UnlinkIdentity(uuid.New())
+	assert.Error(err)
+
+	// Invalid request
+	_, err = client.LinkIdentity(types.LinkIdentityRequest{})
+	assert.ErrorIs(err, types.ErrInvalidLinkIdentityRequest)
+
+	// Link a provider
+	resp, err := client.LinkIdentity(types.LinkIdentityRequest{
+		Provider: types.ProviderGitHub,
+	})
+	require.NoError(err)
+	assert.Contains(resp.AuthorizationURL, "github.com/login/oauth/authorize")
+	assert.Empty(resp.Verifier)
+
+	// Link a provider with PKCE
+	resp, err = client.LinkIdentity(types.LinkIdentityRequest{
+		Provider:   types.ProviderGitHub,
+		FlowType:   types.FlowPKCE,
+		RedirectTo: "http://localhost:3000/auth/callback",
+	})
+	require.NoError(err)
+	assert.Contains(resp.AuthorizationURL, "github.com/login/oauth/authorize")
+	assert.NotEmpty(resp.Verifier)
+
+	// Provider not enabled
+	_, err = client.LinkIdentity(types.LinkIdentityRequest{
+		Provider: types.ProviderApple,
+	})
+	assert.Error(err)
+}
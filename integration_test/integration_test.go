@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/rand"
@@ -144,6 +145,30 @@ func TestWithClient(t *testing.T) {
 	assert.True(roundTripper.visited)
 }
 
+func TestWithContext(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	c := gotrue.New(projectReference, apiKey).WithCustomGoTrueURL("http://localhost:9999")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h, err := c.WithContext(ctx).HealthCheck()
+	require.NoError(err)
+	assert.Equal("GoTrue", h.Name)
+
+	// Requests made with a cancelled context should fail.
+	cancel()
+	_, err = c.WithContext(ctx).HealthCheck()
+	assert.ErrorIs(err, context.Canceled)
+
+	// The original client should be unaffected.
+	h, err = c.HealthCheck()
+	require.NoError(err)
+	assert.Equal("GoTrue", h.Name)
+}
+
 type customRoundTripper struct {
 	visited bool
 }
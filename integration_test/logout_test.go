@@ -36,3 +36,78 @@ func TestLogout(t *testing.T) {
 	_, err = client.RefreshToken(session.RefreshToken)
 	assert.Error(err)
 }
+
+func TestLogoutWithScope(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	client := autoconfirmClient
+
+	email := randomEmail()
+	password := randomString(10)
+	_, err := client.Signup(types.SignupRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(err)
+
+	// Sign in three times, e.g. on three devices.
+	var sessions []*types.TokenResponse
+	for i := 0; i < 3; i++ {
+		session, err := client.SignInWithEmailPassword(email, password)
+		require.NoError(err)
+		sessions = append(sessions, session)
+	}
+
+	// Invalid scope
+	err = client.WithToken(sessions[0].AccessToken).LogoutWithScope("everything")
+	assert.Error(err)
+
+	// Sign out the other sessions.
+	err = client.WithToken(sessions[0].AccessToken).LogoutWithScope(types.LogoutScopeOthers)
+	require.NoError(err)
+	_, err = client.RefreshToken(sessions[1].RefreshToken)
+	assert.Error(err)
+	_, err = client.RefreshToken(sessions[2].RefreshToken)
+	assert.Error(err)
+	refreshed, err := client.RefreshToken(sessions[0].RefreshToken)
+	require.NoError(err)
+
+	// Sign out only the current session.
+	other, err := client.SignInWithEmailPassword(email, password)
+	require.NoError(err)
+	err = client.WithToken(refreshed.AccessToken).LogoutWithScope(types.LogoutScopeLocal)
+	require.NoError(err)
+	_, err = client.RefreshToken(refreshed.RefreshToken)
+	assert.Error(err)
+	_, err = client.RefreshToken(other.RefreshToken)
+	assert.NoError(err)
+}
+
+func TestAdminSignOut(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	admin := withAdmin(autoconfirmClient)
+
+	// Invalid request
+	err := admin.AdminSignOut(types.AdminSignOutRequest{})
+	assert.ErrorIs(err, types.ErrInvalidAdminSignOutRequest)
+
+	// Invalid token
+	err = admin.AdminSignOut(types.AdminSignOutRequest{AccessToken: "invalid"})
+	assert.Error(err)
+
+	session, err := autoconfirmClient.Signup(types.SignupRequest{
+		Email:    randomEmail(),
+		Password: "password",
+	})
+	require.NoError(err)
+
+	err = admin.AdminSignOut(types.AdminSignOutRequest{
+		AccessToken: session.AccessToken,
+	})
+	require.NoError(err)
+	_, err = autoconfirmClient.RefreshToken(session.RefreshToken)
+	assert.Error(err)
+}
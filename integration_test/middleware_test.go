@@ -0,0 +1,52 @@
+package integration_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/middleware"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	email := randomEmail()
+	session, err := autoconfirmClient.Signup(types.SignupRequest{
+		Email:    email,
+		Password: "password",
+	})
+	require.NoError(err)
+
+	var user *types.User
+	var claims *types.Claims
+	handler := middleware.Authenticate(middleware.Options{
+		Client: autoconfirmClient,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ = middleware.UserFromContext(r.Context())
+		claims, _ = middleware.ClaimsFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+session.AccessToken)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(http.StatusOK, w.Code)
+	require.NotNil(user)
+	assert.Equal(email, user.Email)
+	require.NotNil(claims)
+	assert.Equal(session.User.ID.String(), claims.Subject)
+	assert.Equal(types.AAL1, claims.AAL)
+
+	// Invalid token
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+adminToken()+"x")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+}
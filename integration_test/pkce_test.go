@@ -0,0 +1,68 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestPKCE(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	pkce, err := types.GeneratePKCEParams()
+	require.NoError(err)
+
+	// Each of the email flows accepts a code challenge.
+	email := randomEmail()
+	user, err := client.Signup(types.SignupRequest{
+		Email:               email,
+		Password:            "password",
+		CodeChallenge:       pkce.Challenge,
+		CodeChallengeMethod: pkce.ChallengeMethod,
+	})
+	require.NoError(err)
+	assert.Equal(email, user.Email)
+
+	err = client.OTP(types.OTPRequest{
+		Email:               randomEmail(),
+		CreateUser:          true,
+		CodeChallenge:       pkce.Challenge,
+		CodeChallengeMethod: pkce.ChallengeMethod,
+	})
+	assert.NoError(err)
+
+	err = client.Magiclink(types.MagiclinkRequest{
+		Email:               randomEmail(),
+		CodeChallenge:       pkce.Challenge,
+		CodeChallengeMethod: pkce.ChallengeMethod,
+	})
+	assert.NoError(err)
+
+	err = client.Recover(types.RecoverRequest{
+		Email:               email,
+		CodeChallenge:       pkce.Challenge,
+		CodeChallengeMethod: pkce.ChallengeMethod,
+	})
+	assert.NoError(err)
+
+	// A challenge without a method is rejected.
+	err = client.Recover(types.RecoverRequest{
+		Email:         email,
+		CodeChallenge: pkce.Challenge,
+	})
+	assert.Error(err)
+
+	// Unknown codes can't be exchanged.
+	_, err = client.ExchangeCodeForSession("00000000-0000-0000-0000-000000000000", pkce.Verifier)
+	require.Error(err)
+	assert.NotErrorIs(err, types.ErrInvalidTokenRequest)
+	var apiErr *types.APIError
+	assert.ErrorAs(err, &apiErr)
+
+	_, err = client.ExchangeCodeForSession("", pkce.Verifier)
+	assert.ErrorIs(err, types.ErrInvalidTokenRequest)
+}
@@ -0,0 +1,105 @@
+package integration_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestSessionManager(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+	m := gotrue.NewSessionManager(autoconfirmClient, gotrue.SessionManagerOptions{})
+	defer m.Close()
+
+	var eventsMu sync.Mutex
+	var events []gotrue.AuthChangeEvent
+	unsubscribe := m.OnAuthStateChange(func(event gotrue.AuthChangeEvent, session *types.Session) {
+		eventsMu.Lock()
+		defer eventsMu.Unlock()
+		events = append(events, event)
+	})
+
+	// No session yet
+	_, err := m.Session(ctx)
+	assert.ErrorIs(err, gotrue.ErrNoSession)
+	_, err = m.Refresh(ctx)
+	assert.ErrorIs(err, gotrue.ErrNoSession)
+
+	email := randomEmail()
+	signup, err := autoconfirmClient.Signup(types.SignupRequest{
+		Email:    email,
+		Password: "password",
+	})
+	require.NoError(err)
+	m.SetSession(signup.Session)
+
+	// Session is not close to expiry, so it is returned as is.
+	session, err := m.Session(ctx)
+	require.NoError(err)
+	assert.Equal(signup.RefreshToken, session.RefreshToken)
+
+	// Concurrent refreshes must share a single request, otherwise the rotated
+	// refresh token would be reused.
+	var wg sync.WaitGroup
+	results := make([]*types.Session, 10)
+	errs := make([]error, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = m.Refresh(ctx)
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		require.NoError(errs[i])
+		assert.NotEqual(signup.RefreshToken, results[i].RefreshToken)
+	}
+
+	session, err = m.Session(ctx)
+	require.NoError(err)
+	assert.Equal(email, session.User.Email)
+
+	// The refreshed session is usable.
+	c, err := m.Client(ctx)
+	require.NoError(err)
+	user, err := c.GetUser()
+	require.NoError(err)
+	assert.Equal(email, user.Email)
+
+	err = m.SignOut(ctx)
+	require.NoError(err)
+	_, err = m.Session(ctx)
+	assert.ErrorIs(err, gotrue.ErrNoSession)
+
+	eventsMu.Lock()
+	assert.Equal(gotrue.AuthEventSignedIn, events[0])
+	assert.Equal(gotrue.AuthEventSignedOut, events[len(events)-1])
+	refreshed := 0
+	for _, e := range events {
+		if e == gotrue.AuthEventTokenRefreshed {
+			refreshed++
+		}
+	}
+	eventsMu.Unlock()
+	assert.GreaterOrEqual(refreshed, 1)
+
+	unsubscribe()
+	m.SetSession(signup.Session)
+	eventsMu.Lock()
+	assert.Equal(gotrue.AuthEventSignedOut, events[len(events)-1])
+	eventsMu.Unlock()
+
+	// Refreshing with a revoked refresh token fails.
+	_, err = m.Refresh(ctx)
+	assert.Error(err)
+}
@@ -0,0 +1,64 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestSessionStore(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := gotrue.NewMemorySessionStore()
+	c := autoconfirmClient.WithSessionStore(store, gotrue.DefaultStorageKey)
+
+	// Nothing stored yet
+	_, err := c.RestoreSession()
+	assert.ErrorIs(err, gotrue.ErrSessionNotFound)
+	_, err = autoconfirmClient.RestoreSession()
+	assert.ErrorIs(err, gotrue.ErrNoSessionStore)
+
+	email := randomEmail()
+	_, err = c.Signup(types.SignupRequest{
+		Email:    email,
+		Password: "password",
+	})
+	require.NoError(err)
+
+	// Signing in stores the session
+	token, err := c.SignInWithEmailPassword(email, "password")
+	require.NoError(err)
+	stored, err := store.Get(gotrue.DefaultStorageKey)
+	require.NoError(err)
+	assert.Equal(token.RefreshToken, stored.RefreshToken)
+
+	// Refreshing replaces it
+	refreshed, err := c.RefreshToken(token.RefreshToken)
+	require.NoError(err)
+	restored, err := c.RestoreSession()
+	require.NoError(err)
+	assert.Equal(refreshed.RefreshToken, restored.RefreshToken)
+	assert.Equal(email, restored.User.Email)
+
+	// Expired sessions are refreshed when restored
+	expired := *restored
+	expired.ExpiresAt = 0
+	require.NoError(store.Set(gotrue.DefaultStorageKey, expired))
+	restored, err = c.RestoreSession()
+	require.NoError(err)
+	assert.NotEqual(expired.RefreshToken, restored.RefreshToken)
+	stored, err = store.Get(gotrue.DefaultStorageKey)
+	require.NoError(err)
+	assert.Equal(restored.RefreshToken, stored.RefreshToken)
+
+	// Logging out removes it
+	err = c.WithToken(restored.AccessToken).Logout()
+	require.NoError(err)
+	_, err = c.RestoreSession()
+	assert.ErrorIs(err, gotrue.ErrSessionNotFound)
+}
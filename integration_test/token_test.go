@@ -1,9 +1,11 @@
 package integration_test
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -89,6 +91,11 @@ func TestToken(t *testing.T) {
 		Password:  "wrong",
 	})
 	assert.Error(err)
+	assert.True(types.IsInvalidCredentials(err))
+	var apiErr *types.APIError
+	require.ErrorAs(err, &apiErr)
+	assert.Equal(http.StatusBadRequest, apiErr.StatusCode)
+	assert.NotEmpty(apiErr.Message)
 
 	// Test login with refresh token
 	email = randomEmail()
@@ -121,6 +128,24 @@ func TestToken(t *testing.T) {
 	assert.Equal(3600, token.ExpiresIn)
 	assert.InDelta(time.Now().Add(3600*time.Second).Unix(), token.ExpiresAt, float64(time.Second))
 
+	// The session ID stays the same when refreshing.
+	sessionID, err := user.SessionID()
+	require.NoError(err)
+	assert.NotEqual(uuid.Nil, sessionID)
+	refreshedID, err := token.SessionID()
+	require.NoError(err)
+	assert.Equal(sessionID, refreshedID)
+
+	// ID token grant
+	// Will error because the provider is not enabled on the test server, but
+	// the request should be accepted by the client and reach the server.
+	_, err = client.SignInWithIDToken(types.ProviderGoogle, "not.a.jwt", "nonce")
+	require.Error(err)
+	assert.NotErrorIs(err, types.ErrInvalidTokenRequest)
+	require.ErrorAs(err, &apiErr)
+	assert.GreaterOrEqual(apiErr.StatusCode, http.StatusBadRequest)
+	assert.Less(apiErr.StatusCode, http.StatusInternalServerError)
+
 	// Invalid input tests
 	tests := map[string]types.TokenRequest{
 		"invalid_grant_type": {
@@ -157,11 +182,25 @@ func TestToken(t *testing.T) {
 		"pkce/missing_code": {
 			GrantType: "pkce",
 		},
+		"id_token/missing_provider": {
+			GrantType: "id_token",
+			IDToken:   "id_token",
+		},
+		"id_token/missing_id_token": {
+			GrantType: "id_token",
+			Provider:  types.ProviderApple,
+		},
+		"id_token/password_provided": {
+			GrantType: "id_token",
+			Provider:  types.ProviderApple,
+			IDToken:   "id_token",
+			Password:  password,
+		},
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			_, err := client.Token(test)
-			require.Error(err)
+			require.ErrorIs(err, types.ErrInvalidTokenRequest)
 		})
 	}
 }
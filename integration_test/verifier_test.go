@@ -0,0 +1,47 @@
+package integration_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/types"
+	"github.com/supabase-community/gotrue-go/verifier"
+)
+
+func TestVerifier(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	v, err := verifier.New(verifier.Options{
+		JWTSecret: []byte(jwtSecret),
+		Audience:  "authenticated",
+	})
+	require.NoError(err)
+
+	email := randomEmail()
+	session, err := autoconfirmClient.Signup(types.SignupRequest{
+		Email:    email,
+		Password: "password",
+	})
+	require.NoError(err)
+
+	claims, err := v.Verify(context.Background(), session.AccessToken)
+	require.NoError(err)
+	assert.Equal(session.User.ID.String(), claims.Subject)
+	assert.Equal(email, claims.Email)
+	assert.Equal("authenticated", claims.Role)
+	assert.Equal(types.AAL1, claims.AAL)
+	assert.NotEmpty(claims.SessionID)
+	assert.Equal(session.ExpiresAt, claims.Expiry)
+
+	// Tokens signed with another secret are rejected.
+	v, err = verifier.New(verifier.Options{
+		JWTSecret: []byte("not the secret"),
+	})
+	require.NoError(err)
+	_, err = v.Verify(context.Background(), session.AccessToken)
+	assert.ErrorIs(err, verifier.ErrInvalidToken)
+}
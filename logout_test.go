@@ -0,0 +1,74 @@
+package gotrue_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// Start a server that accepts logouts, storing the scope and Authorization
+// header of the last one.
+func newLogoutServer(t *testing.T, scope, auth *string) *testServer {
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		*scope = r.URL.Query().Get("scope")
+		*auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func TestLogoutWithScope(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var scope, auth string
+	srv := newLogoutServer(t, &scope, &auth)
+
+	store := gotrue.NewMemorySessionStore()
+	client := gotrue.New("test", "key").
+		WithCustomGoTrueURL(srv.URL).
+		WithToken("user").
+		WithSessionStore(store, gotrue.DefaultStorageKey)
+	session := types.Session{AccessToken: "user", RefreshToken: "refresh"}
+
+	// Signing out other sessions keeps the stored session.
+	require.NoError(store.Set(gotrue.DefaultStorageKey, session))
+	require.NoError(client.LogoutWithScope(types.LogoutScopeOthers))
+	assert.Equal("others", scope)
+	assert.Equal("Bearer user", auth)
+	_, err := store.Get(gotrue.DefaultStorageKey)
+	assert.NoError(err)
+
+	require.NoError(client.LogoutWithScope(types.LogoutScopeLocal))
+	assert.Equal("local", scope)
+	_, err = store.Get(gotrue.DefaultStorageKey)
+	assert.ErrorIs(err, gotrue.ErrSessionNotFound)
+
+	require.NoError(client.Logout())
+	assert.Empty(scope)
+}
+
+func TestAdminSignOut(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var scope, auth string
+	srv := newLogoutServer(t, &scope, &auth)
+	admin := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithToken("admin")
+
+	// The user's token is sent instead of the admin's.
+	require.NoError(admin.AdminSignOut(types.AdminSignOutRequest{
+		AccessToken: "other-user",
+		Scope:       types.LogoutScopeGlobal,
+	}))
+	assert.Equal("global", scope)
+	assert.Equal("Bearer other-user", auth)
+
+	err := admin.AdminSignOut(types.AdminSignOutRequest{})
+	assert.ErrorIs(err, types.ErrInvalidAdminSignOutRequest)
+	assert.Len(srv.calls(), 1)
+}
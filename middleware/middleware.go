@@ -0,0 +1,229 @@
+// Package middleware provides net/http middleware that authenticates requests
+// using GoTrue access tokens.
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+	"github.com/supabase-community/gotrue-go/verifier"
+)
+
+var (
+	ErrMissingToken     = errors.New("request has no bearer token")
+	ErrInvalidToken     = errors.New("bearer token is invalid")
+	ErrInsufficientRole = errors.New("token does not have the required role")
+	ErrInsufficientAAL  = errors.New("token does not have the required authenticator assurance level")
+)
+
+// ErrorHandler writes the response for a request that failed authentication.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+type Options struct {
+	// Client is used to validate tokens remotely by calling GetUser. Required
+	// if Verifier is nil.
+	Client gotrue.Client
+	// Verifier, if set, is used to validate tokens locally instead of calling
+	// GetUser. In this case, only the claims are available to handlers;
+	// UserFromContext reports false.
+	Verifier *verifier.Verifier
+
+	// RequiredRole, if set, must match the role of the token, e.g.
+	// "authenticated" or "service_role".
+	RequiredRole string
+	// RequiredAAL, if set, is the minimum authenticator assurance level the
+	// token must have. Use types.AAL2 to require the user to have verified a
+	// second factor, e.g. with VerifyFactor.
+	RequiredAAL types.AAL
+
+	// AllowAPIKeys, if true, accepts tokens that don't belong to a user: those
+	// without a subject or with the "anon" role, such as the project's anon and
+	// service_role keys. By default they are rejected. With a Verifier, set
+	// verifier.Options.AllowMissingSubject too.
+	AllowAPIKeys bool
+
+	// Optional, if true, lets requests without a bearer token through
+	// unauthenticated. Requests with an invalid token are still rejected.
+	Optional bool
+
+	// ErrorHandler writes the response when authentication fails. Defaults to
+	// DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+}
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	claimsKey
+	tokenKey
+)
+
+// Get the user attached to the request context by the middleware. Only
+// available if the middleware validated the token with a Client.
+func UserFromContext(ctx context.Context) (*types.User, bool) {
+	u, ok := ctx.Value(userKey).(*types.User)
+	return u, ok
+}
+
+// Get the access token claims attached to the request context by the
+// middleware.
+func ClaimsFromContext(ctx context.Context) (*types.Claims, bool) {
+	c, ok := ctx.Value(claimsKey).(*types.Claims)
+	return c, ok
+}
+
+// Get the access token attached to the request context by the middleware, e.g.
+// to make requests to GoTrue on behalf of the user.
+func AccessTokenFromContext(ctx context.Context) (string, bool) {
+	t, ok := ctx.Value(tokenKey).(string)
+	return t, ok
+}
+
+// Authenticate returns middleware that authenticates requests using the
+// bearer token in the Authorization header.
+//
+// On success, the claims of the token, and the user if validated with a
+// Client, are attached to the request context. See UserFromContext and
+// ClaimsFromContext.
+//
+// Authenticate panics if neither Client nor Verifier is set.
+func Authenticate(opts Options) func(http.Handler) http.Handler {
+	if opts.Client == nil && opts.Verifier == nil {
+		panic("gotrue middleware: Client or Verifier must be set")
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = DefaultErrorHandler
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				if opts.Optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				opts.ErrorHandler(w, r, ErrMissingToken)
+				return
+			}
+
+			ctx, err := authenticate(r.Context(), opts, token)
+			if err != nil {
+				opts.ErrorHandler(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func authenticate(ctx context.Context, opts Options, token string) (context.Context, error) {
+	var claims *types.Claims
+	if opts.Verifier != nil {
+		c, err := opts.Verifier.Verify(ctx, token)
+		if err != nil {
+			// Errors fetching the keys, or a cancelled request, say nothing
+			// about the token.
+			if errors.Is(err, verifier.ErrKeysUnavailable) || (ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+		}
+		claims = c
+	} else {
+		user, err := opts.Client.WithToken(token).WithContext(ctx).GetUser()
+		if err != nil {
+			var apiErr *types.APIError
+			if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+			}
+			return nil, err
+		}
+		ctx = context.WithValue(ctx, userKey, &user.User)
+
+		// GoTrue has validated the token, so it is safe to read the claims
+		// without verifying the signature again.
+		c, err := types.ParseClaims(token)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+		}
+		claims = c
+	}
+
+	if !opts.AllowAPIKeys && (claims.Subject == "" || claims.Role == "anon") {
+		return nil, fmt.Errorf("%w: token does not belong to a user", ErrInvalidToken)
+	}
+	if opts.RequiredRole != "" && claims.Role != opts.RequiredRole {
+		return nil, ErrInsufficientRole
+	}
+	if opts.RequiredAAL != "" && aalRank(claims.AAL) < aalRank(opts.RequiredAAL) {
+		return nil, ErrInsufficientAAL
+	}
+
+	ctx = context.WithValue(ctx, claimsKey, claims)
+	ctx = context.WithValue(ctx, tokenKey, token)
+	return ctx, nil
+}
+
+func aalRank(aal types.AAL) int {
+	switch aal {
+	case types.AAL1:
+		return 1
+	case types.AAL2:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	const prefix = "bearer "
+	if len(h) <= len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(h[len(prefix):])
+	return token, token != ""
+}
+
+// DefaultErrorHandler responds with 401 Unauthorized for missing or invalid
+// tokens, 403 Forbidden for tokens without the required role or AAL, 503
+// Service Unavailable if the verifier couldn't fetch the signing keys, and 500
+// Internal Server Error otherwise, e.g. if GoTrue could not be reached.
+//
+// The body is a JSON object in the same shape as GoTrue's own errors.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	code := "unexpected_failure"
+	msg := "Failed to authenticate request"
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		status, code, msg = http.StatusUnauthorized, "no_authorization", "This endpoint requires a Bearer token"
+	case errors.Is(err, ErrInvalidToken):
+		status, code, msg = http.StatusUnauthorized, "bad_jwt", "Invalid bearer token"
+	case errors.Is(err, ErrInsufficientRole):
+		status, code, msg = http.StatusForbidden, "insufficient_role", "Token does not have the required role"
+	case errors.Is(err, ErrInsufficientAAL):
+		status, code, msg = http.StatusForbidden, "insufficient_aal", "Token does not have the required authenticator assurance level"
+	case errors.Is(err, verifier.ErrKeysUnavailable):
+		status, msg = http.StatusServiceUnavailable, "Signing keys are unavailable"
+	}
+
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="gotrue"`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":       status,
+		"error_code": code,
+		"msg":        msg,
+	})
+}
@@ -0,0 +1,219 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/middleware"
+	"github.com/supabase-community/gotrue-go/types"
+	"github.com/supabase-community/gotrue-go/verifier"
+)
+
+const secret = "secret"
+
+func token(t *testing.T, role string, aal types.AAL) string {
+	return sign(t, jwt.MapClaims{
+		"sub":  "8b0b6a0e-8a43-4d67-9f8c-4b3c2a1d0e9f",
+		"aud":  "authenticated",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": role,
+		"aal":  aal,
+	})
+}
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return s
+}
+
+func TestAuthenticate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	v, err := verifier.New(verifier.Options{JWTSecret: []byte(secret)})
+	require.NoError(err)
+
+	var gotClaims *types.Claims
+	var gotUser bool
+	var gotToken string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotClaims, _ = middleware.ClaimsFromContext(r.Context())
+		_, gotUser = middleware.UserFromContext(r.Context())
+		gotToken, _ = middleware.AccessTokenFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	serve := func(opts middleware.Options, authorization string) *httptest.ResponseRecorder {
+		gotClaims, gotUser, gotToken = nil, false, ""
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		middleware.Authenticate(opts)(handler).ServeHTTP(w, r)
+		return w
+	}
+
+	opts := middleware.Options{Verifier: v}
+
+	// Valid token
+	aal1 := token(t, "authenticated", types.AAL1)
+	w := serve(opts, "Bearer "+aal1)
+	assert.Equal(http.StatusNoContent, w.Code)
+	require.NotNil(gotClaims)
+	assert.Equal("authenticated", gotClaims.Role)
+	assert.False(gotUser)
+	assert.Equal(aal1, gotToken)
+
+	// Scheme is case insensitive
+	w = serve(opts, "bearer "+aal1)
+	assert.Equal(http.StatusNoContent, w.Code)
+
+	// Missing token
+	w = serve(opts, "")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(w.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(`{"code":401,"error_code":"no_authorization","msg":"This endpoint requires a Bearer token"}`, w.Body.String())
+	w = serve(opts, "Basic dXNlcjpwYXNz")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	// Invalid token
+	w = serve(opts, "Bearer nope")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	// Tokens that don't belong to a user
+	anonKey := sign(t, jwt.MapClaims{
+		"iss":  "supabase",
+		"aud":  "authenticated",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": "anon",
+	})
+	w = serve(opts, "Bearer "+anonKey)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	w = serve(opts, "Bearer "+token(t, "anon", ""))
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	lenient, err := verifier.New(verifier.Options{JWTSecret: []byte(secret), AllowMissingSubject: true})
+	require.NoError(err)
+	apiKeys := middleware.Options{Verifier: lenient, AllowAPIKeys: true}
+	w = serve(apiKeys, "Bearer "+anonKey)
+	assert.Equal(http.StatusNoContent, w.Code)
+	require.NotNil(gotClaims)
+	assert.Equal("anon", gotClaims.Role)
+
+	// Optional authentication
+	optional := opts
+	optional.Optional = true
+	w = serve(optional, "")
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Nil(gotClaims)
+	w = serve(optional, "Bearer nope")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	// Role requirement
+	serviceOnly := opts
+	serviceOnly.RequiredRole = "service_role"
+	w = serve(serviceOnly, "Bearer "+aal1)
+	assert.Equal(http.StatusForbidden, w.Code)
+	w = serve(serviceOnly, "Bearer "+token(t, "service_role", ""))
+	assert.Equal(http.StatusNoContent, w.Code)
+
+	// AAL requirement
+	mfaOnly := opts
+	mfaOnly.RequiredAAL = types.AAL2
+	w = serve(mfaOnly, "Bearer "+aal1)
+	assert.Equal(http.StatusForbidden, w.Code)
+	w = serve(mfaOnly, "Bearer "+token(t, "authenticated", types.AAL2))
+	assert.Equal(http.StatusNoContent, w.Code)
+
+	// Custom error handler
+	custom := opts
+	var handledErr error
+	custom.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		handledErr = err
+		w.WriteHeader(http.StatusTeapot)
+	}
+	w = serve(custom, "")
+	assert.Equal(http.StatusTeapot, w.Code)
+	assert.ErrorIs(handledErr, middleware.ErrMissingToken)
+
+	// Misconfiguration
+	assert.Panics(func() {
+		middleware.Authenticate(middleware.Options{})
+	})
+}
+
+func TestAuthenticateKeysUnavailable(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// /slow doesn't respond until the test finishes.
+	slow := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-slow
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	defer close(slow)
+	v, err := verifier.New(verifier.Options{JWKSURL: srv.URL})
+	require.NoError(err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	tok := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"sub":  "8b0b6a0e-8a43-4d67-9f8c-4b3c2a1d0e9f",
+		"aud":  "authenticated",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": "authenticated",
+	})
+	tok.Header["kid"] = "ec-1"
+	signed, err := tok.SignedString(key)
+	require.NoError(err)
+
+	var handledErr error
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called")
+	})
+	serve := func(opts middleware.Options, r *http.Request) *httptest.ResponseRecorder {
+		r.Header.Set("Authorization", "Bearer "+signed)
+		w := httptest.NewRecorder()
+		middleware.Authenticate(opts)(handler).ServeHTTP(w, r)
+		return w
+	}
+
+	// The token can't be checked, so the request fails without blaming the
+	// token.
+	w := serve(middleware.Options{Verifier: v}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+	assert.Empty(w.Header().Get("WWW-Authenticate"))
+
+	// Nor does a cancelled request.
+	v, err = verifier.New(verifier.Options{JWKSURL: srv.URL + "/slow"})
+	require.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := middleware.Options{
+		Verifier: v,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			handledErr = err
+			middleware.DefaultErrorHandler(w, r, err)
+		},
+	}
+	w = serve(opts, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	assert.ErrorIs(handledErr, context.Canceled)
+	assert.NotErrorIs(handledErr, middleware.ErrInvalidToken)
+	assert.Equal(http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,170 @@
+package gotrue_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+type ctxKey struct{}
+
+func TestWithMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var order []string
+	named := func(name string) types.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return types.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+
+	base := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL)
+	client := base.
+		WithMiddleware(named("outer"), named("middle")).
+		WithMiddleware(types.SetHeaders(func(ctx context.Context, h http.Header) {
+			order = append(order, "headers")
+			h.Set("X-Client-Info", "gotrue-go/test")
+			if ip, ok := ctx.Value(ctxKey{}).(string); ok {
+				h.Set("X-Forwarded-For", ip)
+			}
+		}))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "203.0.113.7")
+	_, err := client.WithContext(ctx).WithToken("token").GetSettings()
+	require.NoError(err)
+	assert.Equal([]string{"outer", "middle", "headers"}, order)
+	assert.Equal("gotrue-go/test", headers.Get("X-Client-Info"))
+	assert.Equal("203.0.113.7", headers.Get("X-Forwarded-For"))
+	assert.Equal("key", headers.Get("apiKey"))
+	assert.Equal("Bearer token", headers.Get("Authorization"))
+
+	// Middleware also applies to requests that don't follow redirects.
+	order = nil
+	_, _ = client.Authorize(types.AuthorizeRequest{Provider: types.ProviderGitHub})
+	assert.Equal([]string{"outer", "middle", "headers"}, order)
+
+	// The client the middleware was added to is unchanged.
+	order = nil
+	_, err = base.GetSettings()
+	require.NoError(err)
+	assert.Empty(order)
+	assert.Empty(headers.Get("X-Client-Info"))
+}
+
+func TestWithMiddlewareRetries(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := newFlakyServer(t, 2, http.StatusServiceUnavailable, "")
+
+	attempts := 0
+	client := gotrue.New("test", "key").
+		WithCustomGoTrueURL(srv.URL).
+		WithRetryPolicy(testRetryPolicy).
+		WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+			return types.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				attempts++
+				return next.RoundTrip(r)
+			})
+		})
+
+	_, err := client.GetSettings()
+	require.NoError(err)
+	assert.Len(srv.calls(), 3)
+	assert.Equal(3, attempts)
+}
+
+func TestOperationFromContext(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var ops []string
+	client := gotrue.New("test", "key").
+		WithCustomGoTrueURL(srv.URL).
+		WithToken("token").
+		WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+			return types.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				op, _ := types.OperationFromContext(r.Context())
+				ops = append(ops, op)
+				return next.RoundTrip(r)
+			})
+		})
+
+	_, _ = client.GetSettings()
+	// Convenience methods report the method that sends the request.
+	_, _ = client.SignInWithEmailPassword("user@example.com", "password")
+	// As do methods that share an unexported helper.
+	_ = client.LogoutWithScope(types.LogoutScopeLocal)
+	_ = client.AdminSignOut(types.AdminSignOutRequest{AccessToken: "user"})
+	// Methods built on other methods report the outer method.
+	_, _ = client.ListFactors()
+	assert.Equal([]string{"GetSettings", "Token", "LogoutWithScope", "AdminSignOut", "ListFactors"}, ops)
+}
+
+func TestWithOperationHook(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := newFactorServer(t, 1)
+
+	type event struct {
+		op  string
+		err error
+	}
+	var events []event
+	var attempts []string
+	client := gotrue.New("test", "key").
+		WithCustomGoTrueURL(srv.URL).
+		WithToken("aal1").
+		WithOperationHook(func(ctx context.Context, op string) (context.Context, func(error)) {
+			events = append(events, event{op: op})
+			return context.WithValue(ctx, ctxKey{}, op), func(err error) {
+				events = append(events, event{op: op, err: err})
+			}
+		}).
+		WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+			return types.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				// The hook's context is used for the requests.
+				op, _ := r.Context().Value(ctxKey{}).(string)
+				attempts = append(attempts, op)
+				return next.RoundTrip(r)
+			})
+		})
+
+	// A single operation covers every request the method sends.
+	_, err := client.ChallengeAndVerify(uuid.New(), "123456")
+	require.NoError(err)
+	assert.Equal([]event{{op: "ChallengeAndVerify"}, {op: "ChallengeAndVerify"}}, events)
+	assert.Equal([]string{"ChallengeAndVerify", "ChallengeAndVerify", "ChallengeAndVerify", "ChallengeAndVerify"}, attempts)
+
+	// The method's error is passed to end.
+	events = nil
+	_, err = client.VerifyFactor(types.VerifyFactorRequest{FactorID: uuid.New(), ChallengeID: uuid.New(), Code: "000000"})
+	require.Error(err)
+	require.Len(events, 2)
+	assert.Equal("VerifyFactor", events[1].op)
+	assert.Equal(err, events[1].err)
+}
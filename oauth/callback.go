@@ -0,0 +1,121 @@
+// Package oauth implements the server side of signing in with an OAuth
+// provider through GoTrue.
+//
+// ParseCallback and ParseCallbackURL parse the parameters GoTrue redirects
+// back with. Flow builds on them and on Client.Authorize to provide a complete,
+// CSRF-safe login flow using the PKCE flow and a signed cookie.
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CallbackResult holds the parameters GoTrue redirects back to the app with
+// after signing in with a provider.
+//
+// With the PKCE flow, only Code is set on success. With the implicit flow,
+// the tokens are set instead. On failure, Error is set; see Err.
+type CallbackResult struct {
+	// Code is the auth code to exchange for a session with
+	// ExchangeCodeForSession. Only set for the PKCE flow.
+	Code string
+	// State is the "state" query parameter, if the redirect URL included one.
+	// See Flow.
+	State string
+
+	// The fields below are only set for the implicit flow, where GoTrue passes
+	// the session in the URL fragment.
+	AccessToken          string
+	RefreshToken         string
+	TokenType            string
+	ExpiresIn            int
+	ExpiresAt            int64
+	ProviderToken        string
+	ProviderRefreshToken string
+
+	// The fields below are set if signing in failed, e.g. because the user
+	// declined to authorize the app.
+	Error            string
+	ErrorCode        string
+	ErrorDescription string
+}
+
+// CallbackError is returned by CallbackResult.Err if GoTrue or the provider
+// reported an error.
+type CallbackError struct {
+	// Err is the OAuth2 error, e.g. "access_denied" or "server_error".
+	Err string
+	// Code is GoTrue's machine readable error code, if any.
+	Code string
+	// Description is the human readable error message, if any.
+	Description string
+}
+
+func (e *CallbackError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("oauth callback error: %s", e.Err)
+	}
+	return fmt.Sprintf("oauth callback error: %s: %s", e.Err, e.Description)
+}
+
+// Get the error reported in the callback as a *CallbackError, or nil if there
+// was none.
+func (r *CallbackResult) Err() error {
+	if r.Error == "" && r.ErrorCode == "" {
+		return nil
+	}
+	return &CallbackError{
+		Err:         r.Error,
+		Code:        r.ErrorCode,
+		Description: r.ErrorDescription,
+	}
+}
+
+// Parse the callback parameters from the URL of a request to the callback
+// handler.
+//
+// Browsers never send the URL fragment to the server, so the tokens of the
+// implicit flow are not available this way. Use the PKCE flow for server-side
+// apps, or ParseCallbackURL with a URL captured on the client.
+func ParseCallback(r *http.Request) (*CallbackResult, error) {
+	return ParseCallbackURL(r.URL)
+}
+
+// Parse the callback parameters from a URL that GoTrue redirected to. Both the
+// query and the fragment are read, with values in the fragment taking
+// precedence.
+func ParseCallbackURL(u *url.URL) (*CallbackResult, error) {
+	values := u.Query()
+	if u.Fragment != "" {
+		fragment, err := url.ParseQuery(u.Fragment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse callback URL fragment: %w", err)
+		}
+		for k, v := range fragment {
+			values[k] = v
+		}
+	}
+
+	res := &CallbackResult{
+		Code:  values.Get("code"),
+		State: values.Get("state"),
+
+		AccessToken:          values.Get("access_token"),
+		RefreshToken:         values.Get("refresh_token"),
+		TokenType:            values.Get("token_type"),
+		ProviderToken:        values.Get("provider_token"),
+		ProviderRefreshToken: values.Get("provider_refresh_token"),
+
+		Error:            values.Get("error"),
+		ErrorCode:        values.Get("error_code"),
+		ErrorDescription: values.Get("error_description"),
+	}
+	// Like Verify, leave these as 0 if they can't be parsed.
+	res.ExpiresIn, _ = strconv.Atoi(values.Get("expires_in"))
+	res.ExpiresAt, _ = strconv.ParseInt(values.Get("expires_at"), 10, 64)
+
+	return res, nil
+}
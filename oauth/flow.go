@@ -0,0 +1,249 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+var (
+	ErrNoClient       = errors.New("cannot create oauth flow: a client must be provided")
+	ErrSecretTooShort = errors.New("cannot create oauth flow: secret must be at least 32 bytes long")
+	ErrNoCallbackURL  = errors.New("cannot create oauth flow: an absolute callback URL must be provided")
+	ErrMissingCookie  = errors.New("oauth flow cookie is missing")
+	ErrInvalidCookie  = errors.New("oauth flow cookie is invalid")
+	ErrFlowExpired    = errors.New("oauth flow has expired")
+	ErrStateMismatch  = errors.New("oauth callback state does not match the flow cookie")
+	ErrMissingCode    = errors.New("oauth callback has no code")
+)
+
+const stateParam = "state"
+
+type FlowOptions struct {
+	// Client is used to call Authorize and ExchangeCodeForSession. Required.
+	Client gotrue.Client
+	// Secret is used to sign the flow cookie. It must be at least 32 bytes
+	// long, and kept secret. Required.
+	Secret []byte
+	// CallbackURL is the absolute URL of the handler that calls Callback.
+	// Required.
+	//
+	// A state parameter is added to the URL's query, so the server's redirect
+	// URL settings must allow it with a query string, e.g.
+	// "https://example.com/auth/callback**".
+	CallbackURL string
+
+	// CookieName defaults to "gotrue-oauth-flow".
+	CookieName string
+	// CookiePath defaults to "/". Set it to the path of the callback handler
+	// to avoid sending the cookie with other requests.
+	CookiePath   string
+	CookieDomain string
+	// InsecureCookie, if true, allows the cookie to be sent over plain HTTP.
+	// Only use this for local development.
+	InsecureCookie bool
+	// MaxAge is how long the user has to sign in with the provider. Defaults
+	// to 10 minutes.
+	MaxAge time.Duration
+}
+
+// Flow implements a server-side login flow with an OAuth provider, using the
+// PKCE flow.
+//
+// Start redirects the user to the provider, after storing the PKCE verifier
+// and a random state in a signed cookie. Callback checks that the state in the
+// callback URL matches the cookie, so that the callback can't be forged by
+// another site, and exchanges the code for a session.
+//
+// Only one flow can be in progress per browser at a time: starting another
+// replaces the cookie of the first.
+type Flow struct {
+	opts        FlowOptions
+	callbackURL *url.URL
+}
+
+// Create a login flow. Returns an error if a required option is missing.
+func NewFlow(opts FlowOptions) (*Flow, error) {
+	if opts.Client == nil {
+		return nil, ErrNoClient
+	}
+	if len(opts.Secret) < 32 {
+		return nil, ErrSecretTooShort
+	}
+	u, err := url.Parse(opts.CallbackURL)
+	if err != nil || !u.IsAbs() {
+		return nil, ErrNoCallbackURL
+	}
+	if opts.CookieName == "" {
+		opts.CookieName = "gotrue-oauth-flow"
+	}
+	if opts.CookiePath == "" {
+		opts.CookiePath = "/"
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 10 * time.Minute
+	}
+	return &Flow{opts: opts, callbackURL: u}, nil
+}
+
+// flowCookie is the content of the signed cookie.
+type flowCookie struct {
+	State     string `json:"s"`
+	Verifier  string `json:"v"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// Start signing in with the provider in req.
+//
+// Start calls Authorize with the PKCE flow and a redirect to the callback URL,
+// sets the flow cookie, and redirects the user to the provider. The FlowType
+// and RedirectTo fields of req are overwritten.
+//
+// If an error is returned, nothing has been written to w.
+func (f *Flow) Start(w http.ResponseWriter, r *http.Request, req types.AuthorizeRequest) error {
+	state, err := randomString()
+	if err != nil {
+		return err
+	}
+
+	callbackURL := *f.callbackURL
+	q := callbackURL.Query()
+	q.Set(stateParam, state)
+	callbackURL.RawQuery = q.Encode()
+
+	req.FlowType = types.FlowPKCE
+	req.RedirectTo = callbackURL.String()
+	resp, err := f.opts.Client.WithContext(r.Context()).Authorize(req)
+	if err != nil {
+		return err
+	}
+
+	value, err := f.encode(flowCookie{
+		State:     state,
+		Verifier:  resp.Verifier,
+		ExpiresAt: time.Now().Add(f.opts.MaxAge).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, f.cookie(value, int(f.opts.MaxAge.Seconds())))
+	http.Redirect(w, r, resp.AuthorizationURL, http.StatusFound)
+	return nil
+}
+
+// Finish signing in, in the handler for the callback URL.
+//
+// Callback checks the callback against the flow cookie, clears the cookie and
+// exchanges the code for a session. Errors reported by GoTrue or the provider,
+// e.g. because the user declined to authorize the app, are returned as a
+// *CallbackError, but only once the cookie and state have been checked, so
+// that a forged callback can't interfere with a flow in progress.
+//
+// The caller is responsible for storing the session, e.g. in its own session
+// cookie, and writing the response.
+func (f *Flow) Callback(w http.ResponseWriter, r *http.Request) (*types.TokenResponse, error) {
+	res, err := ParseCallback(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := r.Cookie(f.opts.CookieName)
+	if err != nil {
+		return nil, ErrMissingCookie
+	}
+	flow, err := f.decode(c.Value)
+	if err != nil {
+		return nil, err
+	}
+	if res.State == "" || subtle.ConstantTimeCompare([]byte(res.State), []byte(flow.State)) != 1 {
+		return nil, ErrStateMismatch
+	}
+
+	// The callback belongs to this flow, so the cookie is used up, whatever
+	// the outcome.
+	http.SetCookie(w, f.cookie("", -1))
+
+	if time.Now().Unix() > flow.ExpiresAt {
+		return nil, ErrFlowExpired
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	if res.Code == "" {
+		return nil, ErrMissingCode
+	}
+
+	return f.opts.Client.WithContext(r.Context()).ExchangeCodeForSession(res.Code, flow.Verifier)
+}
+
+func (f *Flow) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     f.opts.CookieName,
+		Value:    value,
+		Path:     f.opts.CookiePath,
+		Domain:   f.opts.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   !f.opts.InsecureCookie,
+		HttpOnly: true,
+		// Lax, rather than Strict, so the cookie is sent when the provider
+		// redirects back to the callback URL.
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Encode the cookie as base64url(json) + "." + base64url(hmac).
+func (f *Flow) encode(c flowCookie) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(f.sign(payload)), nil
+}
+
+func (f *Flow) decode(value string) (*flowCookie, error) {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, ErrInvalidCookie
+	}
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotSig, f.sign(payload)) {
+		return nil, ErrInvalidCookie
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+	var c flowCookie
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCookie, err)
+	}
+	return &c, nil
+}
+
+func (f *Flow) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, f.opts.Secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
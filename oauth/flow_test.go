@@ -0,0 +1,234 @@
+package oauth_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/oauth"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+const callbackURL = "https://app.example.com/auth/callback"
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+// Start a fake GoTrue server that hands out a code for each authorize request,
+// and exchanges it for a session if the verifier matches the challenge.
+func newGoTrue(t *testing.T) *httptest.Server {
+	challenges := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authorize":
+			q := r.URL.Query()
+			code := fmt.Sprintf("code-%d", len(challenges))
+			challenges[code] = q.Get("code_challenge")
+			// Pretend the user signed in with the provider, and was sent
+			// back to redirect_to.
+			redirectTo, err := url.Parse(q.Get("redirect_to"))
+			require.NoError(t, err)
+			rq := redirectTo.Query()
+			rq.Set("code", code)
+			redirectTo.RawQuery = rq.Encode()
+			w.Header().Set("Location", "https://provider.example.com/authorize?next="+url.QueryEscape(redirectTo.String()))
+			w.WriteHeader(http.StatusFound)
+		case "/token":
+			var body struct {
+				AuthCode     string `json:"auth_code"`
+				CodeVerifier string `json:"code_verifier"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			sha := sha256.Sum256([]byte(body.CodeVerifier))
+			if challenges[body.AuthCode] != base64.RawURLEncoding.EncodeToString(sha[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"code":400,"error_code":"bad_code_verifier","msg":"code challenge does not match previously saved code verifier"}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"access","refresh_token":"refresh","token_type":"bearer","expires_in":3600}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Follow the fake provider's redirect back to the callback URL.
+func callbackFromStart(t *testing.T, w *httptest.ResponseRecorder) *http.Request {
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodGet, loc.Query().Get("next"), nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func TestFlow(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := newGoTrue(t)
+	client := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL)
+	flow, err := oauth.NewFlow(oauth.FlowOptions{
+		Client:      client,
+		Secret:      secret,
+		CallbackURL: callbackURL,
+	})
+	require.NoError(err)
+
+	start := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "https://app.example.com/login", nil)
+		require.NoError(flow.Start(w, r, types.AuthorizeRequest{Provider: types.ProviderGitHub}))
+		return w
+	}
+
+	// Successful login
+	w := start()
+	assert.Equal(http.StatusFound, w.Code)
+	cookies := w.Result().Cookies()
+	require.Len(cookies, 1)
+	assert.Equal("gotrue-oauth-flow", cookies[0].Name)
+	assert.True(cookies[0].HttpOnly)
+	assert.True(cookies[0].Secure)
+	assert.Equal(http.SameSiteLaxMode, cookies[0].SameSite)
+
+	r := callbackFromStart(t, w)
+	assert.NotEmpty(r.URL.Query().Get("state"))
+	cw := httptest.NewRecorder()
+	session, err := flow.Callback(cw, r)
+	require.NoError(err)
+	assert.Equal("access", session.AccessToken)
+	// The cookie is cleared.
+	require.Len(cw.Result().Cookies(), 1)
+	assert.Equal(-1, cw.Result().Cookies()[0].MaxAge)
+
+	// Missing cookie, e.g. the callback was opened in another browser.
+	r = callbackFromStart(t, start())
+	r.Header.Del("Cookie")
+	_, err = flow.Callback(httptest.NewRecorder(), r)
+	assert.ErrorIs(err, oauth.ErrMissingCookie)
+
+	// State from another flow, e.g. a callback URL planted by an attacker.
+	r1 := callbackFromStart(t, start())
+	r2 := callbackFromStart(t, start())
+	r1.Header.Del("Cookie")
+	for _, c := range r2.Cookies() {
+		r1.AddCookie(c)
+	}
+	cw = httptest.NewRecorder()
+	_, err = flow.Callback(cw, r1)
+	assert.ErrorIs(err, oauth.ErrStateMismatch)
+	// The other flow's cookie is kept.
+	assert.Empty(cw.Result().Cookies())
+
+	// Tampered cookie
+	r = callbackFromStart(t, start())
+	c, err := r.Cookie("gotrue-oauth-flow")
+	require.NoError(err)
+	r.Header.Del("Cookie")
+	r.AddCookie(&http.Cookie{Name: c.Name, Value: "x" + c.Value})
+	_, err = flow.Callback(httptest.NewRecorder(), r)
+	assert.ErrorIs(err, oauth.ErrInvalidCookie)
+
+	// Expired flow
+	expiring, err := oauth.NewFlow(oauth.FlowOptions{
+		Client:      client,
+		Secret:      secret,
+		CallbackURL: callbackURL,
+		MaxAge:      time.Nanosecond,
+	})
+	require.NoError(err)
+	w = httptest.NewRecorder()
+	require.NoError(expiring.Start(w, httptest.NewRequest(http.MethodGet, "/login", nil), types.AuthorizeRequest{Provider: types.ProviderGitHub}))
+	time.Sleep(1100 * time.Millisecond)
+	_, err = expiring.Callback(httptest.NewRecorder(), callbackFromStart(t, w))
+	assert.ErrorIs(err, oauth.ErrFlowExpired)
+
+	// Error reported by the provider
+	r = callbackFromStart(t, start())
+	errorRequest := func(state string) *http.Request {
+		q := url.Values{
+			"error":             {"access_denied"},
+			"error_description": {"User denied"},
+			"state":             {state},
+		}
+		req := httptest.NewRequest(http.MethodGet, callbackURL+"?"+q.Encode(), nil)
+		for _, c := range r.Cookies() {
+			req.AddCookie(c)
+		}
+		return req
+	}
+	cw = httptest.NewRecorder()
+	_, err = flow.Callback(cw, errorRequest(r.URL.Query().Get("state")))
+	var cbErr *oauth.CallbackError
+	require.True(errors.As(err, &cbErr))
+	assert.Equal("access_denied", cbErr.Err)
+	assert.Equal("User denied", cbErr.Description)
+	require.Len(cw.Result().Cookies(), 1)
+	assert.Equal(-1, cw.Result().Cookies()[0].MaxAge)
+
+	// An error that doesn't match the flow is rejected without clearing the
+	// cookie, so a forged callback can't abort the login.
+	cw = httptest.NewRecorder()
+	_, err = flow.Callback(cw, errorRequest("forged"))
+	assert.ErrorIs(err, oauth.ErrStateMismatch)
+	assert.Empty(cw.Result().Cookies())
+	r.Header.Del("Cookie")
+	_, err = flow.Callback(httptest.NewRecorder(), errorRequest(""))
+	assert.ErrorIs(err, oauth.ErrMissingCookie)
+}
+
+func TestNewFlow(t *testing.T) {
+	assert := assert.New(t)
+
+	client := gotrue.New("test", "key")
+	_, err := oauth.NewFlow(oauth.FlowOptions{Secret: secret, CallbackURL: callbackURL})
+	assert.ErrorIs(err, oauth.ErrNoClient)
+	_, err = oauth.NewFlow(oauth.FlowOptions{Client: client, Secret: []byte("short"), CallbackURL: callbackURL})
+	assert.ErrorIs(err, oauth.ErrSecretTooShort)
+	_, err = oauth.NewFlow(oauth.FlowOptions{Client: client, Secret: secret, CallbackURL: "/auth/callback"})
+	assert.ErrorIs(err, oauth.ErrNoCallbackURL)
+}
+
+func TestParseCallbackURL(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// PKCE flow
+	u, _ := url.Parse(callbackURL + "?code=abc&state=xyz")
+	res, err := oauth.ParseCallbackURL(u)
+	require.NoError(err)
+	assert.Equal("abc", res.Code)
+	assert.Equal("xyz", res.State)
+	assert.NoError(res.Err())
+
+	// Implicit flow
+	u, _ = url.Parse(callbackURL + "#access_token=access&refresh_token=refresh&token_type=bearer&expires_in=3600&expires_at=1700000000&provider_token=ptoken")
+	res, err = oauth.ParseCallbackURL(u)
+	require.NoError(err)
+	assert.Equal("access", res.AccessToken)
+	assert.Equal("refresh", res.RefreshToken)
+	assert.Equal("bearer", res.TokenType)
+	assert.Equal(3600, res.ExpiresIn)
+	assert.EqualValues(1700000000, res.ExpiresAt)
+	assert.Equal("ptoken", res.ProviderToken)
+
+	// Errors can be in either the query or the fragment.
+	u, _ = url.Parse(callbackURL + "#error=server_error&error_code=unexpected_failure&error_description=Oops")
+	res, err = oauth.ParseCallbackURL(u)
+	require.NoError(err)
+	assert.EqualError(res.Err(), "oauth callback error: server_error: Oops")
+}
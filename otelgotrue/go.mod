@@ -0,0 +1,29 @@
+module github.com/supabase-community/gotrue-go/otelgotrue
+
+go 1.19
+
+require (
+	github.com/google/uuid v1.3.0
+	github.com/stretchr/testify v1.8.3
+	github.com/supabase-community/gotrue-go v1.3.0
+	go.opentelemetry.io/otel v1.16.0
+	go.opentelemetry.io/otel/metric v1.16.0
+	go.opentelemetry.io/otel/sdk v1.16.0
+	go.opentelemetry.io/otel/sdk/metric v0.39.0
+	go.opentelemetry.io/otel/trace v1.16.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/tomnomnom/linkheader v0.0.0-20180905144013-02ca5825eb80 // indirect
+	golang.org/x/sys v0.8.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// Build against the client in this repository when developing locally. The
+// replace is ignored when otelgotrue is used as a dependency, which then gets
+// the required release above.
+replace github.com/supabase-community/gotrue-go => ../
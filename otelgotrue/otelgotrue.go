@@ -0,0 +1,250 @@
+// Package otelgotrue instruments GoTrue clients with OpenTelemetry traces and
+// metrics.
+//
+//	client, err := otelgotrue.Instrument(client, otelgotrue.Options{})
+//	if err != nil {
+//		// Handle error...
+//	}
+//
+// Each client method call gets a client span named after the method, e.g.
+// "gotrue.Token" or "gotrue.AdminGetUser", covering every request it sends.
+// Each attempt, including retries, is recorded as an event on the span. The
+// duration and any error of each call are recorded in metrics. By default the
+// global tracer and meter providers are used, so nothing is recorded unless
+// the application has configured OpenTelemetry.
+//
+// This package is a separate module, so that applications that don't use it
+// don't depend on OpenTelemetry.
+package otelgotrue
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/supabase-community/gotrue-go/otelgotrue"
+
+// AttemptEvent is the name of the span event recorded for each attempt.
+const AttemptEvent = "gotrue.attempt"
+
+// Attribute keys set on spans, attempt events and metrics.
+const (
+	// The client method that was called, e.g. "Token".
+	OperationKey = attribute.Key("gotrue.operation")
+	// The path of the request, with IDs replaced by "{id}", e.g.
+	// "/auth/v1/admin/users/{id}". Methods that send several requests report
+	// the last one.
+	EndpointKey = attribute.Key("gotrue.endpoint")
+	// The grant type of Token requests, e.g. "password".
+	GrantTypeKey = attribute.Key("gotrue.grant_type")
+	// The number of the attempt, starting at 1, on attempt events, or the
+	// number of attempts made on spans.
+	AttemptKey    = attribute.Key("gotrue.attempt")
+	MethodKey     = attribute.Key("http.request.method")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	// The class of error, if the call or attempt failed: the GoTrue error code
+	// if the server returned one, e.g. "invalid_credentials", otherwise the
+	// status code, one of "timeout", "canceled" or "transport" if no response
+	// was received, or "_OTHER" for errors returned by the client itself, e.g.
+	// for an invalid request.
+	ErrorTypeKey = attribute.Key("error.type")
+)
+
+type Options struct {
+	// TracerProvider defaults to the global provider.
+	TracerProvider trace.TracerProvider
+	// MeterProvider defaults to the global provider.
+	MeterProvider metric.MeterProvider
+}
+
+// Instrument returns a copy of the client that records a span, the duration
+// and any error of each method call.
+func Instrument(client gotrue.Client, opts Options) (gotrue.Client, error) {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+
+	meter := opts.MeterProvider.Meter(ScopeName)
+	duration, err := meter.Float64Histogram("gotrue.client.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of client method calls, including retries."),
+	)
+	if err != nil {
+		return nil, err
+	}
+	errs, err := meter.Int64Counter("gotrue.client.operation.errors",
+		metric.WithDescription("Number of client method calls that failed."),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &instrumentation{
+		tracer:   opts.TracerProvider.Tracer(ScopeName),
+		duration: duration,
+		errors:   errs,
+	}
+	return client.WithOperationHook(i.start).WithMiddleware(i.middleware), nil
+}
+
+type instrumentation struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+}
+
+// call is the state of a single method call, shared by the attempts it makes.
+type call struct {
+	span  trace.Span
+	start time.Time
+
+	mu sync.Mutex
+	// Attributes of the last attempt, copied to the span and metrics when the
+	// call ends.
+	attrs     []attribute.KeyValue
+	attempts  int
+	errorType string
+}
+
+type callKey struct{}
+
+func (i *instrumentation) start(ctx context.Context, op string) (context.Context, func(error)) {
+	spanCtx, span := i.tracer.Start(ctx, "gotrue."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(OperationKey.String(op)),
+	)
+	c := &call{span: span, start: time.Now()}
+
+	return context.WithValue(spanCtx, callKey{}, c), func(err error) {
+		c.mu.Lock()
+		attrs := append([]attribute.KeyValue{OperationKey.String(op)}, c.attrs...)
+		if c.attempts > 0 {
+			span.SetAttributes(AttemptKey.Int(c.attempts))
+		}
+		if err != nil {
+			attrs = append(attrs, ErrorTypeKey.String(errorType(err, c.errorType)))
+		}
+		c.mu.Unlock()
+
+		span.SetAttributes(attrs...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		// Record metrics with the caller's context rather than the span's, as
+		// the span has ended.
+		set := metric.WithAttributes(attrs...)
+		i.duration.Record(ctx, time.Since(c.start).Seconds(), set)
+		if err != nil {
+			i.errors.Add(ctx, 1, set)
+		}
+	}
+}
+
+func (i *instrumentation) middleware(next http.RoundTripper) http.RoundTripper {
+	return types.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		c, ok := r.Context().Value(callKey{}).(*call)
+		if !ok {
+			return next.RoundTrip(r)
+		}
+
+		attrs := []attribute.KeyValue{
+			EndpointKey.String(endpoint(r.URL.Path)),
+			MethodKey.String(r.Method),
+		}
+		if grantType := r.URL.Query().Get("grant_type"); grantType != "" {
+			attrs = append(attrs, GrantTypeKey.String(grantType))
+		}
+
+		resp, err := next.RoundTrip(r)
+
+		var errorType string
+		if err != nil {
+			errorType = transportErrorType(err)
+		} else {
+			attrs = append(attrs, StatusCodeKey.Int(resp.StatusCode))
+		}
+
+		c.mu.Lock()
+		c.attempts++
+		c.attrs = attrs
+		c.errorType = errorType
+		event := append([]attribute.KeyValue{AttemptKey.Int(c.attempts)}, attrs...)
+		c.mu.Unlock()
+
+		if errorType != "" {
+			event = append(event, ErrorTypeKey.String(errorType))
+		} else if resp.StatusCode >= 400 {
+			event = append(event, ErrorTypeKey.String(strconv.Itoa(resp.StatusCode)))
+		}
+		c.span.AddEvent(AttemptEvent, trace.WithAttributes(event...))
+		return resp, err
+	})
+}
+
+// Replace the IDs in a path with "{id}", so that it can be used as a metric
+// attribute without creating a series per user.
+func endpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if _, err := uuid.Parse(s); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Get the class of the error returned by a method call. lastAttempt is the
+// class of the error of the call's last attempt, if it received no response.
+func errorType(err error, lastAttempt string) string {
+	var apiErr *types.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		if apiErr.ErrorCode != "" {
+			return string(apiErr.ErrorCode)
+		}
+		return strconv.Itoa(apiErr.StatusCode)
+	case lastAttempt != "":
+		return lastAttempt
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "_OTHER"
+	}
+}
+
+func transportErrorType(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "transport"
+	}
+}
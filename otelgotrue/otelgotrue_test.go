@@ -0,0 +1,204 @@
+package otelgotrue_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/otelgotrue"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	var healthChecks int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":400,"error_code":"invalid_credentials","msg":"Invalid login credentials"}`)
+		case "/settings":
+			fmt.Fprint(w, `{}`)
+		case "/user":
+			fmt.Fprint(w, `{"factors":[]}`)
+		case "/health":
+			// Fail the first health check, so that it is retried.
+			if atomic.AddInt32(&healthChecks, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprint(w, `{"code":503,"msg":"Service Unavailable"}`)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":404,"msg":"User not found"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func attrs(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestInstrument(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	srv := newServer(t)
+	client, err := otelgotrue.Instrument(gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithToken("token"), otelgotrue.Options{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	require.NoError(err)
+
+	_, err = client.GetSettings()
+	require.NoError(err)
+
+	_, err = client.SignInWithEmailPassword("user@example.com", "wrong")
+	assert.True(types.IsInvalidCredentials(err))
+
+	userID := uuid.New()
+	_, err = client.AdminGetUser(types.AdminGetUserRequest{UserID: userID})
+	assert.Error(err)
+
+	// Retries are part of the same span.
+	_, err = client.WithRetryPolicy(types.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}).HealthCheck()
+	require.NoError(err)
+
+	// Methods built on other methods get a single span.
+	_, err = client.ListFactors()
+	require.NoError(err)
+
+	// Errors returned by the client itself.
+	err = client.AdminSignOut(types.AdminSignOutRequest{})
+	assert.ErrorIs(err, types.ErrInvalidAdminSignOutRequest)
+
+	ended := spans.Ended()
+	require.Len(ended, 6)
+
+	assert.Equal("gotrue.GetSettings", ended[0].Name())
+	a := attrs(ended[0].Attributes())
+	assert.Equal("GetSettings", a[otelgotrue.OperationKey].AsString())
+	assert.Equal("/settings", a[otelgotrue.EndpointKey].AsString())
+	assert.Equal("GET", a[otelgotrue.MethodKey].AsString())
+	assert.EqualValues(200, a[otelgotrue.StatusCodeKey].AsInt64())
+	assert.EqualValues(1, a[otelgotrue.AttemptKey].AsInt64())
+	assert.NotContains(a, otelgotrue.ErrorTypeKey)
+	assert.Equal(codes.Unset, ended[0].Status().Code)
+	require.Len(ended[0].Events(), 1)
+	assert.Equal(otelgotrue.AttemptEvent, ended[0].Events()[0].Name)
+
+	assert.Equal("gotrue.Token", ended[1].Name())
+	a = attrs(ended[1].Attributes())
+	assert.Equal("password", a[otelgotrue.GrantTypeKey].AsString())
+	assert.EqualValues(400, a[otelgotrue.StatusCodeKey].AsInt64())
+	assert.Equal("invalid_credentials", a[otelgotrue.ErrorTypeKey].AsString())
+	assert.Equal(codes.Error, ended[1].Status().Code)
+
+	assert.Equal("gotrue.AdminGetUser", ended[2].Name())
+	a = attrs(ended[2].Attributes())
+	assert.Equal("/admin/users/{id}", a[otelgotrue.EndpointKey].AsString())
+	assert.Equal("404", a[otelgotrue.ErrorTypeKey].AsString())
+
+	assert.Equal("gotrue.HealthCheck", ended[3].Name())
+	a = attrs(ended[3].Attributes())
+	assert.EqualValues(2, a[otelgotrue.AttemptKey].AsInt64())
+	assert.EqualValues(200, a[otelgotrue.StatusCodeKey].AsInt64())
+	assert.NotContains(a, otelgotrue.ErrorTypeKey)
+	events := ended[3].Events()
+	require.Len(events, 2)
+	a = attrs(events[0].Attributes)
+	assert.EqualValues(1, a[otelgotrue.AttemptKey].AsInt64())
+	assert.EqualValues(503, a[otelgotrue.StatusCodeKey].AsInt64())
+	assert.Equal("503", a[otelgotrue.ErrorTypeKey].AsString())
+	a = attrs(events[1].Attributes)
+	assert.EqualValues(2, a[otelgotrue.AttemptKey].AsInt64())
+	assert.NotContains(a, otelgotrue.ErrorTypeKey)
+
+	assert.Equal("gotrue.ListFactors", ended[4].Name())
+	assert.Equal("/user", attrs(ended[4].Attributes())[otelgotrue.EndpointKey].AsString())
+
+	assert.Equal("gotrue.AdminSignOut", ended[5].Name())
+	a = attrs(ended[5].Attributes())
+	assert.Equal("_OTHER", a[otelgotrue.ErrorTypeKey].AsString())
+	assert.NotContains(a, otelgotrue.EndpointKey)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(reader.Collect(context.Background(), &rm))
+	require.Len(rm.ScopeMetrics, 1)
+	assert.Equal(otelgotrue.ScopeName, rm.ScopeMetrics[0].Scope.Name)
+	metrics := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	duration, ok := metrics["gotrue.client.operation.duration"].(metricdata.Histogram[float64])
+	require.True(ok)
+	assert.Len(duration.DataPoints, 6)
+
+	errs, ok := metrics["gotrue.client.operation.errors"].(metricdata.Sum[int64])
+	require.True(ok)
+	require.Len(errs.DataPoints, 3)
+	ops := map[string]int64{}
+	for _, dp := range errs.DataPoints {
+		op, _ := dp.Attributes.Value(otelgotrue.OperationKey)
+		ops[op.AsString()] = dp.Value
+	}
+	assert.Equal(map[string]int64{"Token": 1, "AdminGetUser": 1, "AdminSignOut": 1}, ops)
+}
+
+func TestInstrumentTransportError(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	spans := tracetest.NewSpanRecorder()
+	srv := newServer(t)
+	client, err := otelgotrue.Instrument(gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL), otelgotrue.Options{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+	})
+	require.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.WithContext(ctx).HealthCheck()
+	assert.Error(err)
+
+	ended := spans.Ended()
+	require.Len(ended, 1)
+	assert.Equal("gotrue.HealthCheck", ended[0].Name())
+	assert.Equal("canceled", attrs(ended[0].Attributes())[otelgotrue.ErrorTypeKey].AsString())
+	assert.Equal(codes.Error, ended[0].Status().Code)
+}
+
+// Without providers configured, the global no-op providers are used.
+func TestInstrumentNoop(t *testing.T) {
+	require := require.New(t)
+
+	srv := newServer(t)
+	client, err := otelgotrue.Instrument(gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL), otelgotrue.Options{})
+	require.NoError(err)
+	_, err = client.GetSettings()
+	require.NoError(err)
+}
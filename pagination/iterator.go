@@ -0,0 +1,192 @@
+// Package pagination walks through every item of GoTrue's paginated endpoints,
+// such as AdminAuditAll and AdminListUsersAll.
+package pagination
+
+import (
+	"context"
+)
+
+// Page is a single page of results, as returned by a PageFetcher.
+type Page[T any] struct {
+	Items []T
+	// NextPage is the number of the next page, or 0 if this is the last page.
+	NextPage uint
+	// TotalPages is the total number of pages, if known.
+	TotalPages uint
+}
+
+// PageFetcher fetches a single page of results. Pages are numbered from 1.
+type PageFetcher[T any] func(ctx context.Context, page uint) (*Page[T], error)
+
+type Options struct {
+	// Prefetch is the maximum number of pages to fetch ahead of the page
+	// currently being read, concurrently. Defaults to 0, where each page is
+	// only fetched once the previous one has been read.
+	//
+	// Pages are only prefetched once the total number of pages is known, i.e.
+	// after the first page has been fetched.
+	Prefetch uint
+}
+
+// Iterator walks through every item of a paginated endpoint, fetching pages
+// lazily as they are needed.
+//
+// Use it like so:
+//
+//	it := client.AdminAuditAll(ctx, req, pagination.Options{})
+//	defer it.Close()
+//	for it.Next() {
+//		entry := it.Value()
+//		// ...
+//	}
+//	if err := it.Err(); err != nil {
+//		// Handle error...
+//	}
+//
+// An Iterator must not be used from multiple goroutines at once.
+type Iterator[T any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	fetch  PageFetcher[T]
+	opts   Options
+
+	items []T
+	idx   int
+
+	nextPage   uint
+	totalPages uint
+	pending    map[uint]*pageResult[T]
+	err        error
+}
+
+type pageResult[T any] struct {
+	done chan struct{}
+	page *Page[T]
+	err  error
+}
+
+// Create an iterator that starts at startPage and uses fetch to get each page.
+// A startPage of 0 is treated as 1.
+//
+// Iteration stops when a page has no next page, fetch returns an error or ctx
+// is cancelled.
+func NewIterator[T any](ctx context.Context, startPage uint, opts Options, fetch PageFetcher[T]) *Iterator[T] {
+	if startPage == 0 {
+		startPage = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Iterator[T]{
+		ctx:      ctx,
+		cancel:   cancel,
+		fetch:    fetch,
+		opts:     opts,
+		idx:      -1,
+		nextPage: startPage,
+		pending:  map[uint]*pageResult[T]{},
+	}
+}
+
+// Advance to the next item, fetching the next page if needed. Returns false
+// when there are no more items or an error occurred; check Err to tell which.
+func (it *Iterator[T]) Next() bool {
+	for {
+		if it.idx+1 < len(it.items) {
+			it.idx++
+			return true
+		}
+		if it.err != nil || it.nextPage == 0 {
+			return false
+		}
+
+		page := it.nextPage
+		res := it.start(page)
+		it.prefetch(page)
+
+		select {
+		case <-res.done:
+		case <-it.ctx.Done():
+			it.fail(it.ctx.Err())
+			return false
+		}
+		delete(it.pending, page)
+		if res.err != nil {
+			it.fail(res.err)
+			return false
+		}
+
+		it.items = res.page.Items
+		it.idx = -1
+		if res.page.TotalPages > it.totalPages {
+			it.totalPages = res.page.TotalPages
+		}
+		it.nextPage = res.page.NextPage
+		if it.nextPage <= page {
+			// Guard against servers pointing back at pages we've already
+			// read, which would loop forever.
+			it.nextPage = 0
+		}
+	}
+}
+
+// Get the current item. Only valid after a call to Next returned true.
+func (it *Iterator[T]) Value() T {
+	return it.items[it.idx]
+}
+
+// Get the error that stopped iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Stop iterating and cancel any page fetches in flight. It is safe to call
+// Close more than once, and after iteration has finished.
+func (it *Iterator[T]) Close() {
+	it.cancel()
+	it.nextPage = 0
+	it.items = nil
+	it.idx = -1
+}
+
+func (it *Iterator[T]) fail(err error) {
+	it.err = err
+	it.items = nil
+	it.idx = -1
+	it.cancel()
+}
+
+// Start fetching the page, unless already started.
+func (it *Iterator[T]) start(page uint) *pageResult[T] {
+	if res, ok := it.pending[page]; ok {
+		return res
+	}
+
+	res := &pageResult[T]{done: make(chan struct{})}
+	it.pending[page] = res
+	go func() {
+		defer close(res.done)
+		res.page, res.err = it.fetch(it.ctx, page)
+	}()
+	return res
+}
+
+// Start fetching up to Prefetch pages after page, if they are known to exist.
+func (it *Iterator[T]) prefetch(page uint) {
+	for i := uint(1); i <= it.opts.Prefetch; i++ {
+		p := page + i
+		if p > it.totalPages {
+			return
+		}
+		it.start(p)
+	}
+}
+
+// Collect every remaining item of the iterator into a slice, then close it.
+func Collect[T any](it *Iterator[T]) ([]T, error) {
+	defer it.Close()
+
+	var all []T
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
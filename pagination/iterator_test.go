@@ -0,0 +1,124 @@
+package pagination_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/pagination"
+)
+
+// fakePages serves totalPages pages of perPage ints, numbered consecutively.
+type fakePages struct {
+	totalPages uint
+	perPage    int
+	failOn     uint
+
+	mu       sync.Mutex
+	fetched  []uint
+	inFlight int
+	maxIn    int
+	release  chan struct{}
+}
+
+func (f *fakePages) fetch(ctx context.Context, page uint) (*pagination.Page[int], error) {
+	f.mu.Lock()
+	f.fetched = append(f.fetched, page)
+	f.inFlight++
+	if f.inFlight > f.maxIn {
+		f.maxIn = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if f.release != nil {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if page == f.failOn {
+		return nil, errors.New("failed")
+	}
+
+	items := make([]int, f.perPage)
+	for i := range items {
+		items[i] = int(page-1)*f.perPage + i
+	}
+	var next uint
+	if page < f.totalPages {
+		next = page + 1
+	}
+	return &pagination.Page[int]{Items: items, NextPage: next, TotalPages: f.totalPages}, nil
+}
+
+func TestIterator(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Page 0 is treated as the first page.
+	f := &fakePages{totalPages: 3, perPage: 2}
+	all, err := pagination.Collect(pagination.NewIterator(ctx, 0, pagination.Options{}, f.fetch))
+	require.NoError(err)
+	assert.Equal([]int{0, 1, 2, 3, 4, 5}, all)
+	assert.Equal([]uint{1, 2, 3}, f.fetched)
+
+	// Starting part way through
+	f = &fakePages{totalPages: 3, perPage: 2}
+	all, err = pagination.Collect(pagination.NewIterator(ctx, 2, pagination.Options{}, f.fetch))
+	require.NoError(err)
+	assert.Equal([]int{2, 3, 4, 5}, all)
+
+	// Empty result
+	f = &fakePages{totalPages: 1, perPage: 0}
+	all, err = pagination.Collect(pagination.NewIterator(ctx, 1, pagination.Options{}, f.fetch))
+	require.NoError(err)
+	assert.Empty(all)
+
+	// Errors stop iteration
+	f = &fakePages{totalPages: 3, perPage: 2, failOn: 2}
+	all, err = pagination.Collect(pagination.NewIterator(ctx, 1, pagination.Options{}, f.fetch))
+	assert.EqualError(err, "failed")
+	assert.Equal([]int{0, 1}, all)
+
+	// Prefetching is bounded
+	f = &fakePages{totalPages: 10, perPage: 1}
+	all, err = pagination.Collect(pagination.NewIterator(ctx, 1, pagination.Options{Prefetch: 3}, f.fetch))
+	require.NoError(err)
+	assert.Len(all, 10)
+	assert.Equal([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, all)
+	assert.LessOrEqual(f.maxIn, 4)
+	assert.Len(f.fetched, 10)
+}
+
+func TestIteratorCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	f := &fakePages{totalPages: 3, perPage: 1, release: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	it := pagination.NewIterator(ctx, 1, pagination.Options{}, f.fetch)
+	defer it.Close()
+
+	cancel()
+	assert.False(it.Next())
+	assert.ErrorIs(it.Err(), context.Canceled)
+	assert.False(it.Next())
+
+	// Close stops iteration without an error
+	f = &fakePages{totalPages: 3, perPage: 1}
+	it = pagination.NewIterator(context.Background(), 1, pagination.Options{}, f.fetch)
+	assert.True(it.Next())
+	it.Close()
+	assert.False(it.Next())
+	assert.NoError(it.Err())
+}
@@ -0,0 +1,136 @@
+package gotrue_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+var testRetryPolicy = types.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     10 * time.Millisecond,
+}
+
+// Start a server that fails the first failures requests with status, then
+// responds to /settings and /signup.
+func newFlakyServer(t *testing.T, failures int, status int, retryAfter string) *testServer {
+	var srv *testServer
+	srv = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n := len(srv.calls())
+		if r.Body != nil {
+			body, _ := io.ReadAll(r.Body)
+			if r.Method == http.MethodPost && len(body) == 0 {
+				t.Errorf("attempt %d sent an empty body", n)
+			}
+		}
+		if n <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			_, _ = fmt.Fprintf(w, `{"code":%d,"msg":%q}`, status, http.StatusText(status))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/settings":
+			_, _ = w.Write([]byte(`{"external":{},"disable_signup":false}`))
+		case "/signup":
+			_, _ = w.Write([]byte(`{"id":"d6b3b6a4-3b1a-4a4e-8d2b-2f0f1c0c2c4e","email":"test@example.com"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	return srv
+}
+
+func TestRetryPolicy(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// No retries by default.
+	srv := newFlakyServer(t, 1, http.StatusServiceUnavailable, "")
+	client := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL)
+	_, err := client.GetSettings()
+	assert.Error(err)
+	assert.Len(srv.calls(), 1)
+
+	// Idempotent requests are retried.
+	srv = newFlakyServer(t, 2, http.StatusServiceUnavailable, "")
+	client = gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithRetryPolicy(testRetryPolicy)
+	_, err = client.GetSettings()
+	require.NoError(err)
+	assert.Len(srv.calls(), 3)
+
+	// Give up after MaxAttempts.
+	srv = newFlakyServer(t, 5, http.StatusBadGateway, "")
+	client = gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithRetryPolicy(testRetryPolicy)
+	_, err = client.GetSettings()
+	var apiErr *types.APIError
+	require.ErrorAs(err, &apiErr)
+	assert.Equal(http.StatusBadGateway, apiErr.StatusCode)
+	assert.Len(srv.calls(), 3)
+
+	// Client errors are not retried.
+	srv = newFlakyServer(t, 1, http.StatusBadRequest, "")
+	client = gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithRetryPolicy(testRetryPolicy)
+	_, err = client.GetSettings()
+	assert.Error(err)
+	assert.Len(srv.calls(), 1)
+
+	// Mutating requests are only retried when opted in, and replay the body.
+	srv = newFlakyServer(t, 1, http.StatusServiceUnavailable, "")
+	client = gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithRetryPolicy(testRetryPolicy)
+	req := types.SignupRequest{Email: "test@example.com", Password: "password"}
+	_, err = client.Signup(req)
+	assert.Error(err)
+	assert.Len(srv.calls(), 1)
+
+	policy := testRetryPolicy
+	policy.RetryNonIdempotent = true
+	_, err = client.WithRetryPolicy(policy).Signup(req)
+	require.NoError(err)
+	assert.Len(srv.calls(), 2)
+
+	// Reauthenticate sends a nonce, so is not retried despite being a GET.
+	srv = newFlakyServer(t, 1, http.StatusServiceUnavailable, "")
+	client = gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithRetryPolicy(testRetryPolicy)
+	err = client.WithToken("token").Reauthenticate()
+	require.ErrorAs(err, &apiErr)
+	assert.Equal(http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Len(srv.calls(), 1)
+}
+
+func TestRetryPolicyRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Retry-After is honoured, even if longer than the backoff.
+	srv := newFlakyServer(t, 1, http.StatusTooManyRequests, "1")
+	policy := testRetryPolicy
+	policy.MaxBackoff = 2 * time.Second
+	client := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithRetryPolicy(policy)
+	start := time.Now()
+	_, err := client.GetSettings()
+	require.NoError(err)
+	assert.Len(srv.calls(), 2)
+	assert.GreaterOrEqual(time.Since(start), time.Second)
+
+	// If Retry-After is longer than MaxBackoff, the response is returned.
+	srv = newFlakyServer(t, 1, http.StatusTooManyRequests, "60")
+	client = gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithRetryPolicy(testRetryPolicy)
+	_, err = client.GetSettings()
+	var apiErr *types.APIError
+	require.ErrorAs(err, &apiErr)
+	assert.Equal(http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Len(srv.calls(), 1)
+}
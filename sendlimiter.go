@@ -0,0 +1,182 @@
+package gotrue
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// SendLimiter limits how often emails and SMS messages can be requested for the
+// same email address or phone number, so that repeat requests are rejected
+// before they reach the server. See WithSendLimiter.
+//
+// Limits are kept in memory, so each process has its own. The server's limits
+// still apply on top.
+//
+// A SendLimiter is safe for concurrent use, and may be shared by several
+// clients.
+type SendLimiter struct {
+	interval time.Duration
+
+	mu        sync.Mutex
+	next      map[string]time.Time
+	lastSweep time.Time
+}
+
+// Create a limiter that allows one send per interval for each email address or
+// phone number. GoTrue's own default is one email or SMS per 60 seconds.
+func NewSendLimiter(interval time.Duration) *SendLimiter {
+	return &SendLimiter{
+		interval: interval,
+		next:     map[string]time.Time{},
+	}
+}
+
+// Get how long until a send is allowed again for the email address or phone
+// number, or 0 if it is allowed now.
+func (l *SendLimiter) RetryAfter(emailOrPhone string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	d := time.Until(l.next[sendLimiterKey(emailOrPhone)])
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Reserve a send for key. If one is not allowed yet, returns false and how
+// long until it is.
+func (l *SendLimiter) reserve(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	if next, ok := l.next[key]; ok && now.Before(next) {
+		return next.Sub(now), false
+	}
+	l.next[key] = now.Add(l.interval)
+	return 0, true
+}
+
+// Release a reservation made for a send that failed, so it can be tried again
+// straight away.
+func (l *SendLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.next, key)
+}
+
+// Block sends for key for at least d, e.g. because the server reported a
+// longer limit than the limiter's interval.
+func (l *SendLimiter) delay(key string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := time.Now().Add(d)
+	if next.After(l.next[key]) {
+		l.next[key] = next
+	}
+}
+
+// Remove expired entries, at most once per interval, so the map doesn't grow
+// without bound.
+func (l *SendLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.interval {
+		return
+	}
+	l.lastSweep = now
+	for key, next := range l.next {
+		if !now.Before(next) {
+			delete(l.next, key)
+		}
+	}
+}
+
+// Normalise an email address or phone number, so that e.g. "User@Example.com"
+// and "user@example.com", or "+1 555 0100" and "15550100", share a limit.
+func sendLimiterKey(emailOrPhone string) string {
+	s := strings.TrimSpace(emailOrPhone)
+	if strings.Contains(s, "@") {
+		return "email:" + strings.ToLower(s)
+	}
+	return "phone:" + strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// Run send if the limiter allows a send for emailOrPhone. Otherwise, returns a
+// *types.RateLimitError without calling send.
+func (c client) limitSend(emailOrPhone string, send func() error) error {
+	if c.limiter == nil || strings.TrimSpace(emailOrPhone) == "" {
+		return send()
+	}
+
+	key := sendLimiterKey(emailOrPhone)
+	if wait, ok := c.limiter.reserve(key); !ok {
+		return &types.RateLimitError{RetryAfter: wait}
+	}
+
+	err := send()
+	var rlErr *types.RateLimitError
+	switch {
+	case errors.As(err, &rlErr):
+		// Keep the reservation, extended if the server asked us to wait
+		// longer.
+		c.limiter.delay(key, rlErr.RetryAfter)
+	case err != nil:
+		c.limiter.release(key)
+	}
+	return err
+}
+
+// The methods below shadow those of the embedded *endpoints.Client so that
+// sends are limited when a send limiter is configured.
+
+func (c client) OTP(req types.OTPRequest) error {
+	key := req.Email
+	if key == "" {
+		key = req.Phone
+	}
+	return c.limitSend(key, func() error {
+		return c.Client.OTP(req)
+	})
+}
+
+func (c client) Magiclink(req types.MagiclinkRequest) error {
+	return c.limitSend(req.Email, func() error {
+		return c.Client.Magiclink(req)
+	})
+}
+
+func (c client) Recover(req types.RecoverRequest) error {
+	return c.limitSend(req.Email, func() error {
+		return c.Client.Recover(req)
+	})
+}
+
+func (c client) Signup(req types.SignupRequest) (*types.SignupResponse, error) {
+	key := req.Email
+	if key == "" {
+		key = req.Phone
+	}
+	var res *types.SignupResponse
+	err := c.limitSend(key, func() error {
+		var err error
+		res, err = c.Client.Signup(req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,112 @@
+package gotrue_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestRateLimitError(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/otp":
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"code":429,"error_code":"over_email_send_rate_limit","msg":"For security purposes, you can only request this after 42 seconds."}`)
+		case "/recover":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"code":429,"error_code":"over_request_rate_limit","msg":"Request rate limit reached"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":400,"msg":"Bad request"}`)
+		}
+	}))
+	defer srv.Close()
+	client := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL)
+
+	err := client.OTP(types.OTPRequest{Email: "test@example.com"})
+	var rlErr *types.RateLimitError
+	require.ErrorAs(err, &rlErr)
+	assert.Equal(42*time.Second, rlErr.RetryAfter)
+	require.NotNil(rlErr.APIError)
+	assert.Equal(types.ErrorCodeOverEmailSendRateLimit, rlErr.APIError.ErrorCode)
+	assert.True(types.IsRateLimited(err))
+
+	// The underlying *types.APIError is still available.
+	var apiErr *types.APIError
+	require.ErrorAs(err, &apiErr)
+	assert.Equal(http.StatusTooManyRequests, apiErr.StatusCode)
+
+	err = client.Recover(types.RecoverRequest{Email: "test@example.com"})
+	require.ErrorAs(err, &rlErr)
+	assert.Equal(7*time.Second, rlErr.RetryAfter)
+
+	err = client.Magiclink(types.MagiclinkRequest{Email: "test@example.com"})
+	assert.False(errors.As(err, &rlErr))
+	assert.False(types.IsRateLimited(err))
+}
+
+func TestSendLimiter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var calls int32
+	var fail int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	limiter := gotrue.NewSendLimiter(time.Minute)
+	client := gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).WithSendLimiter(limiter)
+
+	require.NoError(client.OTP(types.OTPRequest{Email: "Test@Example.com"}))
+	assert.EqualValues(1, atomic.LoadInt32(&calls))
+
+	// Repeat sends to the same address are rejected without a request, even
+	// through a different endpoint.
+	err := client.Magiclink(types.MagiclinkRequest{Email: " test@example.com"})
+	var rlErr *types.RateLimitError
+	require.ErrorAs(err, &rlErr)
+	assert.Nil(rlErr.APIError)
+	assert.InDelta(time.Minute.Seconds(), rlErr.RetryAfter.Seconds(), 1)
+	assert.InDelta(time.Minute.Seconds(), limiter.RetryAfter("TEST@example.com").Seconds(), 1)
+	assert.EqualValues(1, atomic.LoadInt32(&calls))
+
+	// Other addresses and phone numbers have their own limits.
+	require.NoError(client.Recover(types.RecoverRequest{Email: "other@example.com"}))
+	require.NoError(client.OTP(types.OTPRequest{Phone: "+1 555 0100"}))
+	assert.Error(client.OTP(types.OTPRequest{Phone: "15550100"}))
+	assert.EqualValues(3, atomic.LoadInt32(&calls))
+
+	// Failed sends don't count towards the limit.
+	atomic.StoreInt32(&fail, 1)
+	_, err = client.Signup(types.SignupRequest{Email: "new@example.com", Password: "password"})
+	assert.Error(err)
+	assert.False(types.IsRateLimited(err))
+	assert.Zero(limiter.RetryAfter("new@example.com"))
+	assert.EqualValues(4, atomic.LoadInt32(&calls))
+
+	// Clients without a limiter are not affected.
+	atomic.StoreInt32(&fail, 0)
+	require.NoError(gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL).OTP(types.OTPRequest{Email: "test@example.com"}))
+}
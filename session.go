@@ -0,0 +1,419 @@
+package gotrue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+var (
+	ErrNoSession = errors.New("session manager has no session - sign in and call SetSession first")
+)
+
+// AuthChangeEvent is emitted by a SessionManager whenever the state of its
+// session changes.
+type AuthChangeEvent string
+
+const (
+	// A new session was set on the manager.
+	AuthEventSignedIn AuthChangeEvent = "SIGNED_IN"
+	// The session was refreshed and the refresh token rotated.
+	AuthEventTokenRefreshed AuthChangeEvent = "TOKEN_REFRESHED"
+	// The session was removed from the manager, or the server rejected its
+	// refresh token, e.g. because the session was revoked.
+	AuthEventSignedOut AuthChangeEvent = "SIGNED_OUT"
+	// An attempt to refresh the session failed. The previous session is kept,
+	// so the refresh may be retried.
+	AuthEventRefreshFailed AuthChangeEvent = "REFRESH_FAILED"
+)
+
+// AuthChangeListener is called with each event emitted by a SessionManager.
+// The session is nil for AuthEventSignedOut. For AuthEventRefreshFailed, it is
+// the session that failed to refresh.
+type AuthChangeListener func(event AuthChangeEvent, session *types.Session)
+
+type SessionManagerOptions struct {
+	// RefreshMargin is how long before the access token expires that the
+	// session should be refreshed. Defaults to 60 seconds.
+	RefreshMargin time.Duration
+
+	// AutoRefresh, if true, refreshes the session in the background when it
+	// comes within RefreshMargin of expiring, instead of waiting for the next
+	// call to Session. Call Close to stop the background refresh.
+	AutoRefresh bool
+
+	// RetryInterval is how long to wait before retrying a failed refresh.
+	// Until then, Session returns the current session without refreshing it,
+	// as long as it has not expired. Defaults to 10 seconds.
+	RetryInterval time.Duration
+}
+
+const (
+	defaultRefreshMargin = 60 * time.Second
+	defaultRetryInterval = 10 * time.Second
+)
+
+// SessionManager keeps track of a user's session and refreshes it before it
+// expires.
+//
+// GoTrue rotates the refresh token each time it is used, so refreshing the
+// same session twice can fail, or worse, revoke the session. SessionManager
+// ensures only one refresh is ever in flight: concurrent callers that need a
+// refresh share the result of the same request.
+//
+// A SessionManager is safe for concurrent use.
+type SessionManager struct {
+	client Client
+	opts   SessionManagerOptions
+
+	mu        sync.Mutex
+	session   *types.Session
+	inflight  *refreshCall
+	failedAt  time.Time
+	listeners map[int]AuthChangeListener
+	nextID    int
+	timer     *time.Timer
+	closed    bool
+}
+
+type refreshCall struct {
+	done    chan struct{}
+	session *types.Session
+	err     error
+}
+
+// Create a new session manager that uses the given client to refresh sessions.
+//
+// The client does not need a token set. The manager sets the token itself
+// when making requests on behalf of the session.
+func NewSessionManager(client Client, opts SessionManagerOptions) *SessionManager {
+	if opts.RefreshMargin <= 0 {
+		opts.RefreshMargin = defaultRefreshMargin
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = defaultRetryInterval
+	}
+	return &SessionManager{
+		client:    client,
+		opts:      opts,
+		listeners: map[int]AuthChangeListener{},
+	}
+}
+
+// Register a listener to be called for each auth state change. Listeners are
+// called synchronously, in no particular order, so they should not block.
+//
+// Call the returned function to remove the listener.
+func (m *SessionManager) OnAuthStateChange(listener AuthChangeListener) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextID
+	m.nextID++
+	m.listeners[id] = listener
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.listeners, id)
+	}
+}
+
+// Set the session to manage, e.g. the session returned by Token, VerifyFactor
+// or VerifyForUser. Emits AuthEventSignedIn.
+//
+// The expiry is taken from ExpiresAt, ExpiresIn or, if neither is set, the
+// access token. If it can't be found, the session is only refreshed by calls
+// to Refresh.
+func (m *SessionManager) SetSession(session types.Session) {
+	m.mu.Lock()
+	m.setSessionLocked(&session)
+	m.mu.Unlock()
+
+	m.emit(AuthEventSignedIn, &session)
+}
+
+// Get the current session, refreshing it first if it is within RefreshMargin
+// of expiring. If the refresh fails but the session has not expired yet, the
+// current session is returned, and the refresh is not tried again for
+// RetryInterval.
+//
+// If the server rejects the refresh token, the session is removed and
+// AuthEventSignedOut emitted.
+//
+// If the session was refreshed but couldn't be saved to the client's session
+// store, the new session is returned along with a *SessionStoreError.
+//
+// Returns ErrNoSession if no session has been set.
+func (m *SessionManager) Session(ctx context.Context) (*types.Session, error) {
+	m.mu.Lock()
+	if m.session == nil {
+		m.mu.Unlock()
+		return nil, ErrNoSession
+	}
+	current := *m.session
+	now := time.Now()
+	expired := !now.Before(time.Unix(current.ExpiresAt, 0))
+	if !m.needsRefreshLocked() || (!expired && now.Sub(m.failedAt) < m.opts.RetryInterval) {
+		m.mu.Unlock()
+		return &current, nil
+	}
+	call := m.startRefreshLocked()
+	m.mu.Unlock()
+
+	s, err := m.wait(ctx, call)
+	if s == nil && !expired && !isRefreshTokenRejected(err) {
+		// The access token can still be used, unless the session was replaced
+		// or removed in the meantime.
+		m.mu.Lock()
+		unchanged := m.session != nil && m.session.RefreshToken == current.RefreshToken
+		m.mu.Unlock()
+		if unchanged {
+			return &current, nil
+		}
+	}
+	return s, err
+}
+
+// Refresh the session now, regardless of when it expires.
+//
+// If a refresh is already in progress, this waits for it to finish and
+// returns its result instead of starting another one.
+func (m *SessionManager) Refresh(ctx context.Context) (*types.Session, error) {
+	m.mu.Lock()
+	if m.session == nil {
+		m.mu.Unlock()
+		return nil, ErrNoSession
+	}
+	call := m.startRefreshLocked()
+	m.mu.Unlock()
+
+	return m.wait(ctx, call)
+}
+
+// Get a copy of the client using the current session's access token,
+// refreshing the session first if needed.
+func (m *SessionManager) Client(ctx context.Context) (Client, error) {
+	s, err := m.Session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.client.WithToken(s.AccessToken).WithContext(ctx), nil
+}
+
+// Log the user out and remove the session from the manager. Emits
+// AuthEventSignedOut.
+//
+// The session is removed even if the logout request fails, in which case the
+// error is returned.
+func (m *SessionManager) SignOut(ctx context.Context) error {
+	m.mu.Lock()
+	session := m.session
+	m.setSessionLocked(nil)
+	m.mu.Unlock()
+
+	if session == nil {
+		return nil
+	}
+	m.emit(AuthEventSignedOut, nil)
+
+	return m.client.WithToken(session.AccessToken).WithContext(ctx).Logout()
+}
+
+// Remove the session from the manager without logging out. Emits
+// AuthEventSignedOut if there was a session.
+func (m *SessionManager) Clear() {
+	m.mu.Lock()
+	hadSession := m.session != nil
+	m.setSessionLocked(nil)
+	m.mu.Unlock()
+
+	if hadSession {
+		m.emit(AuthEventSignedOut, nil)
+	}
+}
+
+// Stop any background refresh. The manager can still be used, but sessions
+// will only be refreshed on demand.
+func (m *SessionManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
+
+func (m *SessionManager) setSessionLocked(session *types.Session) {
+	if session != nil && session.ExpiresAt == 0 {
+		if session.ExpiresIn > 0 {
+			session.ExpiresAt = time.Now().Add(time.Duration(session.ExpiresIn) * time.Second).Unix()
+		} else if claims, err := session.Claims(); err == nil {
+			session.ExpiresAt = claims.Expiry
+		}
+	}
+	m.session = session
+	m.failedAt = time.Time{}
+	m.scheduleLocked()
+}
+
+// Check whether the session is within RefreshMargin of expiring. Sessions
+// whose expiry is unknown are never refreshed automatically, as each refresh
+// would use up the refresh token; call Refresh to refresh them.
+func (m *SessionManager) needsRefreshLocked() bool {
+	if m.session.ExpiresAt == 0 {
+		return false
+	}
+	expiresAt := time.Unix(m.session.ExpiresAt, 0)
+	return time.Until(expiresAt) < m.opts.RefreshMargin
+}
+
+// Schedule a background refresh for when the session comes within
+// RefreshMargin of expiring.
+func (m *SessionManager) scheduleLocked() {
+	if m.session != nil && m.session.ExpiresAt == 0 {
+		// The expiry is unknown, so there is nothing to schedule.
+		if m.timer != nil {
+			m.timer.Stop()
+			m.timer = nil
+		}
+		return
+	}
+	var delay time.Duration
+	if m.session != nil {
+		delay = time.Until(time.Unix(m.session.ExpiresAt, 0)) - m.opts.RefreshMargin
+	}
+	m.scheduleAfterLocked(delay)
+}
+
+// Schedule a background refresh after the given delay. Any previously
+// scheduled refresh is cancelled.
+func (m *SessionManager) scheduleAfterLocked(delay time.Duration) {
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	if !m.opts.AutoRefresh || m.closed || m.session == nil {
+		return
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	m.timer = time.AfterFunc(delay, func() {
+		// Errors are reported to listeners by the refresh itself.
+		_, _ = m.Refresh(context.Background())
+	})
+}
+
+// Start a refresh if one is not already in flight, and return the call to
+// wait on.
+func (m *SessionManager) startRefreshLocked() *refreshCall {
+	if m.inflight != nil {
+		return m.inflight
+	}
+
+	call := &refreshCall{done: make(chan struct{})}
+	m.inflight = call
+	refreshToken := m.session.RefreshToken
+
+	// The refresh runs detached from the caller's context. If the caller gave
+	// up half way through, the server may already have rotated the refresh
+	// token, and the new one must not be lost.
+	go func() {
+		res, err := m.client.RefreshToken(refreshToken)
+
+		m.mu.Lock()
+		m.inflight = nil
+		var event AuthChangeEvent
+		var eventSession *types.Session
+		switch {
+		case m.session == nil || m.session.RefreshToken != refreshToken:
+			// The session was replaced or removed while refreshing. The result
+			// is stale, so it is discarded.
+			if err == nil {
+				err = errors.New("session changed while refreshing")
+			}
+		case res == nil && isRefreshTokenRejected(err):
+			// Retrying won't help, so the user is signed out.
+			m.setSessionLocked(nil)
+			event = AuthEventSignedOut
+		case res == nil:
+			event = AuthEventRefreshFailed
+			s := *m.session
+			eventSession = &s
+			m.failedAt = time.Now()
+			m.scheduleAfterLocked(m.opts.RetryInterval)
+		default:
+			// The session is used even if it couldn't be saved to the
+			// client's session store, in which case err is a
+			// *SessionStoreError, as the old refresh token has been used up.
+			s := res.Session
+			m.setSessionLocked(&s)
+			event = AuthEventTokenRefreshed
+			eventSession = &s
+			call.session = &s
+		}
+		call.err = err
+		m.mu.Unlock()
+
+		close(call.done)
+		if event != "" {
+			m.emit(event, eventSession)
+		}
+	}()
+
+	return call
+}
+
+// Check whether a refresh failed because the server rejected the refresh
+// token, e.g. because it was revoked or already used, rather than because of
+// a transient error.
+func isRefreshTokenRejected(err error) bool {
+	var apiErr *types.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
+
+func (m *SessionManager) wait(ctx context.Context, call *refreshCall) (*types.Session, error) {
+	select {
+	case <-call.done:
+		if call.session == nil {
+			return nil, call.err
+		}
+		s := *call.session
+		return &s, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *SessionManager) emit(event AuthChangeEvent, session *types.Session) {
+	m.mu.Lock()
+	listeners := make([]AuthChangeListener, 0, len(m.listeners))
+	for _, l := range m.listeners {
+		listeners = append(listeners, l)
+	}
+	m.mu.Unlock()
+
+	for _, l := range listeners {
+		if session == nil {
+			l(event, nil)
+			continue
+		}
+		// Give each listener its own copy so they can't interfere.
+		s := *session
+		l(event, &s)
+	}
+}
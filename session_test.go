@@ -0,0 +1,175 @@
+package gotrue_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// Start a server that hands out a new session for each refresh, after a short
+// delay so that concurrent callers overlap.
+func newRefreshServer(t *testing.T) (*httptest.Server, *int32) {
+	var refreshes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/token" || r.URL.Query().Get("grant_type") != "refresh_token" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := atomic.AddInt32(&refreshes, 1)
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d","token_type":"bearer","expires_in":3600}`, n, n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &refreshes
+}
+
+func TestSessionManagerSingleRefresh(t *testing.T) {
+	assert := assert.New(t)
+
+	srv, refreshes := newRefreshServer(t)
+	m := gotrue.NewSessionManager(gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL), gotrue.SessionManagerOptions{})
+	defer m.Close()
+	m.SetSession(types.Session{
+		AccessToken:  "access-0",
+		RefreshToken: "refresh-0",
+		ExpiresAt:    time.Now().Add(time.Second).Unix(),
+	})
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s, err := m.Session(context.Background())
+			if assert.NoError(err) {
+				tokens[i] = s.RefreshToken
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.EqualValues(1, atomic.LoadInt32(refreshes))
+	for _, token := range tokens {
+		assert.Equal("refresh-1", token)
+	}
+}
+
+func TestSessionManagerUnknownExpiry(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv, refreshes := newRefreshServer(t)
+	m := gotrue.NewSessionManager(gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL), gotrue.SessionManagerOptions{
+		AutoRefresh: true,
+	})
+	defer m.Close()
+
+	// Neither ExpiresAt nor ExpiresIn, and an opaque access token.
+	m.SetSession(types.Session{AccessToken: "opaque", RefreshToken: "refresh-0"})
+	for i := 0; i < 3; i++ {
+		s, err := m.Session(context.Background())
+		require.NoError(err)
+		assert.Equal("refresh-0", s.RefreshToken)
+	}
+	assert.EqualValues(0, atomic.LoadInt32(refreshes))
+
+	// An explicit refresh still works.
+	s, err := m.Refresh(context.Background())
+	require.NoError(err)
+	assert.Equal("refresh-1", s.RefreshToken)
+}
+
+func TestSessionManagerRefreshTokenRejected(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"code":400,"error_code":"refresh_token_not_found","msg":"Invalid Refresh Token: Refresh Token Not Found"}`)
+	})
+	m := gotrue.NewSessionManager(gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL), gotrue.SessionManagerOptions{
+		AutoRefresh:   true,
+		RetryInterval: 10 * time.Millisecond,
+	})
+	defer m.Close()
+
+	var mu sync.Mutex
+	var events []gotrue.AuthChangeEvent
+	m.OnAuthStateChange(func(event gotrue.AuthChangeEvent, _ *types.Session) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+	m.SetSession(types.Session{
+		AccessToken:  "access-0",
+		RefreshToken: "refresh-0",
+		ExpiresAt:    time.Now().Add(time.Second).Unix(),
+	})
+
+	_, err := m.Session(context.Background())
+	var apiErr *types.APIError
+	require.ErrorAs(err, &apiErr)
+	assert.Equal(http.StatusBadRequest, apiErr.StatusCode)
+
+	// The session is gone and isn't refreshed again.
+	_, err = m.Session(context.Background())
+	assert.ErrorIs(err, gotrue.ErrNoSession)
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(srv.calls(), 1)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal([]gotrue.AuthChangeEvent{gotrue.AuthEventSignedIn, gotrue.AuthEventSignedOut}, events)
+}
+
+func TestSessionManagerRefreshFailedBeforeExpiry(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var srv *testServer
+	srv = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if len(srv.calls()) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprint(w, `{"code":503,"msg":"Service Unavailable"}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"access_token":"access-1","refresh_token":"refresh-1","token_type":"bearer","expires_in":3600}`)
+	})
+	m := gotrue.NewSessionManager(gotrue.New("test", "key").WithCustomGoTrueURL(srv.URL), gotrue.SessionManagerOptions{
+		RetryInterval: 100 * time.Millisecond,
+	})
+	defer m.Close()
+	m.SetSession(types.Session{
+		AccessToken:  "access-0",
+		RefreshToken: "refresh-0",
+		ExpiresAt:    time.Now().Add(time.Minute).Unix(),
+	})
+
+	// The refresh fails, but the access token is still valid, so it is used,
+	// and the refresh isn't retried until RetryInterval has passed.
+	for i := 0; i < 3; i++ {
+		s, err := m.Session(context.Background())
+		require.NoError(err)
+		assert.Equal("access-0", s.AccessToken)
+	}
+	assert.Len(srv.calls(), 1)
+
+	time.Sleep(100 * time.Millisecond)
+	s, err := m.Session(context.Background())
+	require.NoError(err)
+	assert.Equal("refresh-1", s.RefreshToken)
+	assert.Len(srv.calls(), 2)
+}
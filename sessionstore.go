@@ -0,0 +1,225 @@
+package gotrue
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found in store")
+	ErrNoSessionStore  = errors.New("client has no session store - use WithSessionStore to set one")
+)
+
+// DefaultStorageKey is a suggested key to use with WithSessionStore when only
+// one session needs to be stored.
+const DefaultStorageKey = "gotrue-session"
+
+// SessionStore persists sessions, keyed by a storage key.
+//
+// See WithSessionStore for how the client uses it. Implementations must be
+// safe for concurrent use.
+type SessionStore interface {
+	// Get the session stored under key. Returns ErrSessionNotFound if there is
+	// none.
+	Get(key string) (*types.Session, error)
+	// Store the session under key, replacing any existing session.
+	Set(key string, session types.Session) error
+	// Remove the session stored under key. It is not an error if there is none.
+	Delete(key string) error
+}
+
+// SessionStoreError is returned, along with the response, when a session was
+// obtained from the server but could not be saved to the session store.
+//
+// The session is still valid, and the server may already have rotated the
+// refresh token it replaced, so it must not be discarded. Session holds a copy
+// of it.
+type SessionStoreError struct {
+	Session types.Session
+	Err     error
+}
+
+func (e *SessionStoreError) Error() string {
+	return fmt.Sprintf("failed to store session: %s", e.Err)
+}
+
+func (e *SessionStoreError) Unwrap() error {
+	return e.Err
+}
+
+// MemorySessionStore keeps sessions in memory. Sessions are lost when the
+// process exits.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]types.Session
+}
+
+var _ SessionStore = &MemorySessionStore{}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: map[string]types.Session{},
+	}
+}
+
+func (s *MemorySessionStore) Get(key string) (*types.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[key]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (s *MemorySessionStore) Set(key string, session types.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[key] = session
+	return nil
+}
+
+func (s *MemorySessionStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, key)
+	return nil
+}
+
+// Sessions are restored as-is until they are this close to expiring, after
+// which RestoreSession refreshes them first.
+const restoreRefreshMargin = 60 * time.Second
+
+func (c client) storeSession(session types.Session) error {
+	if c.store == nil {
+		return nil
+	}
+	if err := c.store.Set(c.storageKey, session); err != nil {
+		return &SessionStoreError{Session: session, Err: err}
+	}
+	return nil
+}
+
+func (c client) RestoreSession() (*types.Session, error) {
+	if c.store == nil {
+		return nil, ErrNoSessionStore
+	}
+
+	session, err := c.store.Get(c.storageKey)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := session.ExpiresAt
+	if expiresAt == 0 {
+		if claims, err := session.Claims(); err == nil {
+			expiresAt = claims.Expiry
+		}
+	}
+	// Sessions whose expiry is unknown are returned as-is, as refreshing them
+	// on every restore would use up the refresh token each time.
+	if expiresAt == 0 || time.Until(time.Unix(expiresAt, 0)) > restoreRefreshMargin {
+		return session, nil
+	}
+
+	res, err := c.RefreshToken(session.RefreshToken)
+	if res == nil {
+		return nil, err
+	}
+	// Return the new session even if it couldn't be stored, as the old
+	// refresh token has been used up.
+	return &res.Session, err
+}
+
+// The methods below shadow those of the embedded *endpoints.Client so that
+// sessions are persisted when a session store is configured. If the session
+// can't be stored, the response is returned along with a *SessionStoreError.
+
+func (c client) Token(req types.TokenRequest) (*types.TokenResponse, error) {
+	res, err := c.Client.Token(req)
+	if err != nil {
+		return nil, err
+	}
+	return res, c.storeSession(res.Session)
+}
+
+func (c client) SignInWithEmailPassword(email, password string) (*types.TokenResponse, error) {
+	return c.Token(types.TokenRequest{
+		GrantType: "password",
+		Email:     email,
+		Password:  password,
+	})
+}
+
+func (c client) SignInWithPhonePassword(phone, password string) (*types.TokenResponse, error) {
+	return c.Token(types.TokenRequest{
+		GrantType: "password",
+		Phone:     phone,
+		Password:  password,
+	})
+}
+
+func (c client) ExchangeCodeForSession(code, codeVerifier string) (*types.TokenResponse, error) {
+	return c.Token(types.TokenRequest{
+		GrantType:    "pkce",
+		Code:         code,
+		CodeVerifier: codeVerifier,
+	})
+}
+
+func (c client) SignInWithIDToken(provider types.Provider, idToken, nonce string) (*types.TokenResponse, error) {
+	return c.Token(types.TokenRequest{
+		GrantType: "id_token",
+		Provider:  provider,
+		IDToken:   idToken,
+		Nonce:     nonce,
+	})
+}
+
+func (c client) RefreshToken(refreshToken string) (*types.TokenResponse, error) {
+	return c.Token(types.TokenRequest{
+		GrantType:    "refresh_token",
+		RefreshToken: refreshToken,
+	})
+}
+
+func (c client) SignInAnonymously(req types.SignInAnonymouslyRequest) (*types.SignInAnonymouslyResponse, error) {
+	res, err := c.Client.SignInAnonymously(req)
+	if err != nil {
+		return nil, err
+	}
+	return res, c.storeSession(res.Session)
+}
+
+func (c client) Logout() error {
+	return c.removeSession(c.Client.Logout())
+}
+
+func (c client) LogoutWithScope(scope types.LogoutScope) error {
+	err := c.Client.LogoutWithScope(scope)
+	if scope == types.LogoutScopeOthers {
+		// The current session is still valid.
+		return err
+	}
+	return c.removeSession(err)
+}
+
+// Remove the stored session after logging out, returning the logout error
+// err, or the error removing the session if there was none.
+func (c client) removeSession(err error) error {
+	if c.store != nil {
+		// Remove the session even if the request failed. The caller wants to
+		// be logged out, and a session that can't be logged out is most likely
+		// already invalid.
+		if delErr := c.store.Delete(c.storageKey); delErr != nil && err == nil {
+			err = fmt.Errorf("failed to delete stored session: %w", delErr)
+		}
+	}
+	return err
+}
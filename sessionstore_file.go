@@ -0,0 +1,173 @@
+package gotrue
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+var (
+	ErrInvalidEncryptionKey = errors.New("encryption key must be 16, 24 or 32 bytes long")
+)
+
+type FileSessionStoreOptions struct {
+	// EncryptionKey, if set, is used to encrypt sessions at rest using
+	// AES-GCM. It must be 16, 24 or 32 bytes long, selecting AES-128, AES-192
+	// or AES-256.
+	//
+	// Sessions written with one key cannot be read with another, or without a
+	// key.
+	EncryptionKey []byte
+}
+
+// FileSessionStore keeps each session in its own file in a directory.
+//
+// Files are written atomically and are only readable by the current user.
+// Sessions contain refresh tokens, so consider setting an EncryptionKey if the
+// directory may be read by others, e.g. via backups.
+type FileSessionStore struct {
+	dir  string
+	aead cipher.AEAD
+
+	mu sync.Mutex
+}
+
+var _ SessionStore = &FileSessionStore{}
+
+// Create a session store that keeps sessions in dir. The directory is created
+// if it does not exist.
+func NewFileSessionStore(dir string, opts FileSessionStoreOptions) (*FileSessionStore, error) {
+	s := &FileSessionStore{dir: dir}
+
+	if opts.EncryptionKey != nil {
+		switch len(opts.EncryptionKey) {
+		case 16, 24, 32:
+		default:
+			return nil, ErrInvalidEncryptionKey
+		}
+		block, err := aes.NewCipher(opts.EncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		s.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSessionStore) Get(key string) (*types.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.aead != nil {
+		data, err = s.decrypt(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var session types.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode stored session: %w", err)
+	}
+	return &session, nil
+}
+
+func (s *FileSessionStore) Set(key string, session types.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if s.aead != nil {
+		data, err = s.encrypt(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write to a temporary file in the same directory, then rename it over
+	// the old file, so readers never see a partially written session.
+	// CreateTemp creates the file with 0600 permissions.
+	f, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, s.path(key))
+}
+
+func (s *FileSessionStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Keys are encoded so that any key maps to a single, valid file name.
+func (s *FileSessionStore) path(key string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(key))+".session")
+}
+
+func (s *FileSessionStore) encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *FileSessionStore) decrypt(ciphertext []byte) ([]byte, error) {
+	n := s.aead.NonceSize()
+	if len(ciphertext) < n {
+		return nil, errors.New("failed to decrypt stored session: data too short")
+	}
+	plaintext, err := s.aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt stored session: %w", err)
+	}
+	return plaintext, nil
+}
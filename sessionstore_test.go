@@ -0,0 +1,189 @@
+package gotrue_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go"
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestFileSessionStore(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir := filepath.Join(t.TempDir(), "sessions")
+	store, err := gotrue.NewFileSessionStore(dir, gotrue.FileSessionStoreOptions{})
+	require.NoError(err)
+
+	_, err = store.Get("missing")
+	assert.ErrorIs(err, gotrue.ErrSessionNotFound)
+	assert.NoError(store.Delete("missing"))
+
+	session := types.Session{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresAt:    1234,
+	}
+	require.NoError(store.Set("a/b", session))
+
+	got, err := store.Get("a/b")
+	require.NoError(err)
+	assert.Equal(session, *got)
+
+	// Only the session file should be left behind, readable by the owner only.
+	entries, err := os.ReadDir(dir)
+	require.NoError(err)
+	require.Len(entries, 1)
+	info, err := entries[0].Info()
+	require.NoError(err)
+	assert.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(store.Delete("a/b"))
+	_, err = store.Get("a/b")
+	assert.ErrorIs(err, gotrue.ErrSessionNotFound)
+}
+
+func TestFileSessionStoreEncryption(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir := t.TempDir()
+	_, err := gotrue.NewFileSessionStore(dir, gotrue.FileSessionStoreOptions{
+		EncryptionKey: []byte("too short"),
+	})
+	assert.ErrorIs(err, gotrue.ErrInvalidEncryptionKey)
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	store, err := gotrue.NewFileSessionStore(dir, gotrue.FileSessionStoreOptions{
+		EncryptionKey: key,
+	})
+	require.NoError(err)
+
+	session := types.Session{RefreshToken: "super-secret-refresh-token"}
+	require.NoError(store.Set(gotrue.DefaultStorageKey, session))
+
+	got, err := store.Get(gotrue.DefaultStorageKey)
+	require.NoError(err)
+	assert.Equal(session, *got)
+
+	// The token must not be readable from the file.
+	entries, err := os.ReadDir(dir)
+	require.NoError(err)
+	require.Len(entries, 1)
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(err)
+	assert.NotContains(string(data), "super-secret-refresh-token")
+
+	// A store with a different key, or no key, can't read it.
+	other, err := gotrue.NewFileSessionStore(dir, gotrue.FileSessionStoreOptions{
+		EncryptionKey: []byte("fedcba9876543210fedcba9876543210"),
+	})
+	require.NoError(err)
+	_, err = other.Get(gotrue.DefaultStorageKey)
+	assert.Error(err)
+
+	plain, err := gotrue.NewFileSessionStore(dir, gotrue.FileSessionStoreOptions{})
+	require.NoError(err)
+	_, err = plain.Get(gotrue.DefaultStorageKey)
+	assert.Error(err)
+}
+
+// failingStore is a session store whose Set always fails.
+type failingStore struct {
+	*gotrue.MemorySessionStore
+}
+
+func (s failingStore) Set(key string, session types.Session) error {
+	return errors.New("disk full")
+}
+
+func TestSessionStoreError(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv, refreshes := newRefreshServer(t)
+	store := failingStore{gotrue.NewMemorySessionStore()}
+	require.NoError(store.MemorySessionStore.Set(gotrue.DefaultStorageKey, types.Session{
+		AccessToken:  "access-0",
+		RefreshToken: "refresh-0",
+		ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+	}))
+	client := gotrue.New("test", "key").
+		WithCustomGoTrueURL(srv.URL).
+		WithSessionStore(store, gotrue.DefaultStorageKey)
+
+	// The refreshed session is returned even though it couldn't be stored.
+	session, err := client.RestoreSession()
+	var storeErr *gotrue.SessionStoreError
+	require.ErrorAs(err, &storeErr)
+	require.NotNil(session)
+	assert.Equal("refresh-1", session.RefreshToken)
+	assert.Equal("refresh-1", storeErr.Session.RefreshToken)
+
+	// The session manager keeps the new session, rather than retrying with
+	// the used refresh token.
+	m := gotrue.NewSessionManager(client, gotrue.SessionManagerOptions{})
+	defer m.Close()
+	m.SetSession(types.Session{
+		AccessToken:  "access-1",
+		RefreshToken: "refresh-1",
+		ExpiresAt:    time.Now().Add(time.Second).Unix(),
+	})
+	session, err = m.Session(context.Background())
+	require.ErrorAs(err, &storeErr)
+	require.NotNil(session)
+	assert.Equal("refresh-2", session.RefreshToken)
+
+	session, err = m.Session(context.Background())
+	require.NoError(err)
+	assert.Equal("refresh-2", session.RefreshToken)
+	assert.EqualValues(2, atomic.LoadInt32(refreshes))
+}
+
+// Build an unsigned access token that expires at exp.
+func tokenExpiringAt(exp int64) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"user","exp":%d}`, exp)))
+	return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9." + payload + ".c2lnbmF0dXJl"
+}
+
+func TestRestoreSessionExpiry(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv, refreshes := newRefreshServer(t)
+	store := gotrue.NewMemorySessionStore()
+	client := gotrue.New("test", "key").
+		WithCustomGoTrueURL(srv.URL).
+		WithSessionStore(store, gotrue.DefaultStorageKey)
+
+	// Without ExpiresAt, the expiry is read from the access token.
+	valid := tokenExpiringAt(time.Now().Add(time.Hour).Unix())
+	require.NoError(store.Set(gotrue.DefaultStorageKey, types.Session{AccessToken: valid, RefreshToken: "refresh-0"}))
+	session, err := client.RestoreSession()
+	require.NoError(err)
+	assert.Equal("refresh-0", session.RefreshToken)
+
+	expiring := tokenExpiringAt(time.Now().Add(10 * time.Second).Unix())
+	require.NoError(store.Set(gotrue.DefaultStorageKey, types.Session{AccessToken: expiring, RefreshToken: "refresh-0"}))
+	session, err = client.RestoreSession()
+	require.NoError(err)
+	assert.Equal("refresh-1", session.RefreshToken)
+
+	// Sessions whose expiry is unknown are not refreshed.
+	require.NoError(store.Set(gotrue.DefaultStorageKey, types.Session{AccessToken: "opaque", RefreshToken: "refresh-0"}))
+	session, err = client.RestoreSession()
+	require.NoError(err)
+	assert.Equal("refresh-0", session.RefreshToken)
+	assert.EqualValues(1, atomic.LoadInt32(refreshes))
+}
@@ -0,0 +1,61 @@
+package gotrue_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testServer is a fake GoTrue server that records the requests it receives.
+type testServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []string
+}
+
+// Start a server that responds with handler, and is closed when the test
+// finishes.
+//
+// The handler runs on the server's goroutine, so it must report problems with
+// t.Errorf and an error response rather than require, which would stop the
+// wrong goroutine.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *testServer {
+	s := &testServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		s.mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Get the last path segment of each request received so far, e.g. "verify"
+// for /factors/{id}/verify.
+func (s *testServer) calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *testServer) resetCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// Decode the JSON request body into v. If it can't be decoded, fail the test,
+// respond with 400 and return false.
+func decodeBody(t *testing.T, w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		t.Errorf("%s %s: invalid request body: %v", r.Method, r.URL.Path, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package totp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidQRCode = errors.New("invalid TOTP QR code: expected an SVG image")
+
+// maxQRCodeSize is the largest width or height, in pixels, of an image
+// rendered by QRCodePNG, so that a malformed SVG can't exhaust memory.
+const maxQRCodeSize = 4096
+
+// Get the SVG image from TOTPObject.QRCode. GoTrue returns either the SVG
+// document itself or a data URI containing it; both are accepted.
+func QRCodeSVG(qrCode string) ([]byte, error) {
+	s := strings.TrimSpace(qrCode)
+	if strings.HasPrefix(s, "data:") {
+		meta, data, ok := strings.Cut(s, ",")
+		if !ok {
+			return nil, ErrInvalidQRCode
+		}
+		if strings.HasSuffix(meta, ";base64") {
+			b, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidQRCode, err)
+			}
+			s = string(b)
+		} else if unescaped, err := url.PathUnescape(data); err == nil {
+			s = unescaped
+		} else {
+			s = data
+		}
+	}
+	if !strings.Contains(s, "<svg") {
+		return nil, ErrInvalidQRCode
+	}
+	return []byte(s), nil
+}
+
+// Render TOTPObject.QRCode as a PNG image, with each pixel of the SVG scaled
+// up by scale. A scale less than 1 is treated as 1. Images wider or taller
+// than 4096 pixels after scaling are rejected.
+//
+// Only SVG images made of rectangles, like those GoTrue generates, are
+// supported.
+func QRCodePNG(qrCode string, scale int) ([]byte, error) {
+	svg, err := QRCodeSVG(qrCode)
+	if err != nil {
+		return nil, err
+	}
+	img, err := rasterize(svg, scale)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type rect struct {
+	x, y, w, h float64
+	dark       bool
+}
+
+// Draw the rectangles of the SVG onto a white image.
+func rasterize(svg []byte, scale int) (*image.Gray, error) {
+	if scale < 1 {
+		scale = 1
+	}
+
+	var width, height float64
+	var rects []rect
+	dec := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidQRCode, err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch el.Name.Local {
+		case "svg":
+			if width, err = parseLength(el, "width"); err != nil {
+				return nil, err
+			}
+			if height, err = parseLength(el, "height"); err != nil {
+				return nil, err
+			}
+		case "rect":
+			r := rect{dark: isDark(el)}
+			for _, l := range []struct {
+				name string
+				v    *float64
+			}{{"x", &r.x}, {"y", &r.y}, {"width", &r.w}, {"height", &r.h}} {
+				if *l.v, err = parseLength(el, l.name); err != nil {
+					return nil, err
+				}
+			}
+			if r.w == 0 || r.h == 0 {
+				return nil, fmt.Errorf("%w: rect has no width or height", ErrInvalidQRCode)
+			}
+			rects = append(rects, r)
+			width = math.Max(width, r.x+r.w)
+			height = math.Max(height, r.y+r.h)
+		}
+	}
+	if len(rects) == 0 || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: no rectangles found", ErrInvalidQRCode)
+	}
+	if math.Ceil(width)*float64(scale) > maxQRCodeSize || math.Ceil(height)*float64(scale) > maxQRCodeSize {
+		return nil, fmt.Errorf("%w: image larger than %d pixels", ErrInvalidQRCode, maxQRCodeSize)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, int(math.Ceil(width))*scale, int(math.Ceil(height))*scale))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for _, r := range rects {
+		c := color.Gray{Y: 0xff}
+		if r.dark {
+			c = color.Gray{Y: 0}
+		}
+		x0, y0 := int(math.Round(r.x))*scale, int(math.Round(r.y))*scale
+		x1, y1 := int(math.Round(r.x+r.w))*scale, int(math.Round(r.y+r.h))*scale
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				img.SetGray(x, y, c)
+			}
+		}
+	}
+	return img, nil
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Parse an SVG length attribute such as "10" or "10px". Missing attributes
+// and other units are not supported, and parse as 0. Lengths that are negative
+// or not finite, e.g. "Inf" or "NaN", are rejected.
+func parseLength(el xml.StartElement, name string) (float64, error) {
+	s := attr(el, name)
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "px"), 64)
+	if err != nil {
+		return 0, nil
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+		return 0, fmt.Errorf("%w: invalid %s %q", ErrInvalidQRCode, name, s)
+	}
+	return v, nil
+}
+
+// Check whether a rect is filled with a dark colour, from either its fill
+// attribute or its style. Rects without a fill are black, as in SVG.
+func isDark(el xml.StartElement) bool {
+	fill := attr(el, "fill")
+	for _, decl := range strings.Split(attr(el, "style"), ";") {
+		if k, v, ok := strings.Cut(decl, ":"); ok && strings.TrimSpace(k) == "fill" {
+			fill = v
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(fill)) {
+	case "white", "#fff", "#ffffff", "none", "transparent":
+		return false
+	default:
+		return true
+	}
+}
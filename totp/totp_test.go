@@ -0,0 +1,189 @@
+package totp_test
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/totp"
+)
+
+func b32(s string) string {
+	return base32.StdEncoding.EncodeToString([]byte(s))
+}
+
+// Test vectors from RFC 6238, appendix B.
+func TestGenerate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	keys := map[totp.Algorithm]string{
+		totp.AlgorithmSHA1:   b32("12345678901234567890"),
+		totp.AlgorithmSHA256: b32("12345678901234567890123456789012"),
+		totp.AlgorithmSHA512: b32("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	tests := []struct {
+		time int64
+		alg  totp.Algorithm
+		code string
+	}{
+		{59, totp.AlgorithmSHA1, "94287082"},
+		{59, totp.AlgorithmSHA256, "46119246"},
+		{59, totp.AlgorithmSHA512, "90693936"},
+		{1111111109, totp.AlgorithmSHA1, "07081804"},
+		{1111111109, totp.AlgorithmSHA256, "68084774"},
+		{1111111109, totp.AlgorithmSHA512, "25091201"},
+		{1234567890, totp.AlgorithmSHA1, "89005924"},
+		{2000000000, totp.AlgorithmSHA256, "90698825"},
+		{20000000000, totp.AlgorithmSHA512, "47863826"},
+	}
+	for _, test := range tests {
+		key := &totp.Key{
+			Secret:    keys[test.alg],
+			Algorithm: test.alg,
+			Digits:    8,
+			Period:    30 * time.Second,
+		}
+		code, err := key.Generate(time.Unix(test.time, 0))
+		require.NoError(err)
+		assert.Equal(test.code, code, "%s at %d", test.alg, test.time)
+	}
+
+	// 6 digit codes are the last 6 digits.
+	code, err := totp.NewKey(keys[totp.AlgorithmSHA1]).Generate(time.Unix(59, 0))
+	require.NoError(err)
+	assert.Equal("287082", code)
+
+	_, err = totp.NewKey("not base32!").Generate(time.Now())
+	assert.ErrorIs(err, totp.ErrInvalidSecret)
+	_, err = (&totp.Key{Secret: keys[totp.AlgorithmSHA1], Algorithm: "MD5", Digits: 6, Period: time.Minute}).Generate(time.Now())
+	assert.ErrorIs(err, totp.ErrInvalidAlgorithm)
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Lower case and without padding, as authenticator apps accept it.
+	key := totp.NewKey("gezdgnbvgy3tqojqgezdgnbvgy3tqojq")
+	now := time.Unix(1111111109, 0)
+	code, err := key.Generate(now)
+	require.NoError(err)
+
+	assert.True(key.Validate(code, now, 0))
+	assert.False(key.Validate(code, now.Add(30*time.Second), 0))
+	assert.True(key.Validate(code, now.Add(30*time.Second), 1))
+	assert.True(key.Validate(code, now.Add(-30*time.Second), 1))
+	assert.False(key.Validate(code, now.Add(90*time.Second), 1))
+	assert.False(key.Validate("000000", now, 1))
+	assert.False(key.Validate(code[:5], now, 1))
+}
+
+func TestParseURI(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	key, err := totp.ParseURI("otpauth://totp/example.com:user@example.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=example.com")
+	require.NoError(err)
+	assert.Equal("example.com", key.Issuer)
+	assert.Equal("user@example.com", key.AccountName)
+	assert.Equal("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", key.Secret)
+	assert.Equal(totp.AlgorithmSHA1, key.Algorithm)
+	assert.Equal(6, key.Digits)
+	assert.Equal(30*time.Second, key.Period)
+
+	key, err = totp.ParseURI("otpauth://totp/user?secret=GEZDGNBVGY3TQOJQ&algorithm=sha256&digits=8&period=60")
+	require.NoError(err)
+	assert.Empty(key.Issuer)
+	assert.Equal("user", key.AccountName)
+	assert.Equal(totp.AlgorithmSHA256, key.Algorithm)
+	assert.Equal(8, key.Digits)
+	assert.Equal(time.Minute, key.Period)
+
+	for _, uri := range []string{
+		"https://example.com",
+		"otpauth://hotp/user?secret=GEZDGNBVGY3TQOJQ",
+		"otpauth://totp/user",
+		"otpauth://totp/user?secret=GEZDGNBVGY3TQOJQ&period=0",
+		"otpauth://totp/user?secret=GEZDGNBVGY3TQOJQ&digits=x",
+	} {
+		_, err := totp.ParseURI(uri)
+		assert.ErrorIs(err, totp.ErrInvalidURI, uri)
+	}
+	_, err = totp.ParseURI("otpauth://totp/user?secret=GEZDGNBVGY3TQOJQ&digits=4")
+	assert.ErrorIs(err, totp.ErrInvalidDigits)
+}
+
+// A 3x2 image in the style GoTrue generates, with a dark top-left and
+// bottom-right module.
+const testSVG = `<?xml version="1.0"?>
+<svg width="3" height="2" xmlns="http://www.w3.org/2000/svg">
+<rect x="0" y="0" width="1" height="1" style="fill:black;stroke:none"/>
+<rect x="1" y="0" width="1" height="1" style="fill:white;stroke:none"/>
+<rect x="2" y="1" width="1" height="1" style="fill:black;stroke:none"/>
+</svg>`
+
+func TestQRCode(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	for _, qrCode := range []string{
+		testSVG,
+		"data:image/svg+xml;utf-8," + testSVG,
+		"data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(testSVG)),
+	} {
+		svg, err := totp.QRCodeSVG(qrCode)
+		require.NoError(err)
+		assert.Equal(testSVG, string(svg))
+	}
+	_, err := totp.QRCodeSVG("data:image/png;base64,AAAA")
+	assert.ErrorIs(err, totp.ErrInvalidQRCode)
+
+	data, err := totp.QRCodePNG(testSVG, 2)
+	require.NoError(err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(err)
+	assert.Equal(6, img.Bounds().Dx())
+	assert.Equal(4, img.Bounds().Dy())
+
+	dark := func(x, y int) bool {
+		r, _, _, _ := img.At(x, y).RGBA()
+		return r == 0
+	}
+	assert.True(dark(0, 0))
+	assert.True(dark(1, 1))
+	assert.False(dark(2, 0))
+	assert.False(dark(4, 0))
+	assert.True(dark(5, 3))
+	assert.False(dark(0, 3))
+}
+
+func TestQRCodeMalformed(t *testing.T) {
+	assert := assert.New(t)
+
+	for name, svg := range map[string]string{
+		"huge_width":    `<svg width="1e9" height="1"><rect width="1" height="1"/></svg>`,
+		"huge_rect":     `<svg><rect x="0" y="0" width="1e9" height="1e9"/></svg>`,
+		"inf_width":     `<svg width="Inf" height="1"><rect width="1" height="1"/></svg>`,
+		"nan_rect":      `<svg><rect x="NaN" width="1" height="1"/></svg>`,
+		"negative_rect": `<svg><rect x="0" width="-5" height="1"/></svg>`,
+		"empty_rect":    `<svg><rect x="0" y="0" width="0" height="1"/></svg>`,
+		"no_rects":      `<svg width="3" height="3"></svg>`,
+		"truncated":     `<svg width="3" height="3"><rect`,
+	} {
+		_, err := totp.QRCodePNG(svg, 1)
+		assert.ErrorIs(err, totp.ErrInvalidQRCode, name)
+	}
+
+	// The size limit applies after scaling.
+	_, err := totp.QRCodePNG(testSVG, 2000)
+	assert.ErrorIs(err, totp.ErrInvalidQRCode)
+	_, err = totp.QRCodePNG(testSVG, 1000)
+	assert.NoError(err)
+}
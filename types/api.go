@@ -25,8 +25,12 @@ func (e *ErrInvalidGenerateLinkRequest) Error() string {
 
 var (
 	ErrInvalidAdminAuditRequest        = errors.New("admin audit request is invalid - if Query is not nil, then query Column must be author, action or type, and value must be given")
+	ErrInvalidAdminListUsersRequest    = errors.New("admin list users request is invalid - if Sort is not nil, then sort Column must be given and Direction must be asc or desc")
+	ErrInvalidAdminSignOutRequest      = errors.New("admin sign out request is invalid - access token must be provided")
 	ErrInvalidAdminUpdateFactorRequest = errors.New("admin update factor request is invalid - nothing to update")
-	ErrInvalidTokenRequest             = errors.New("token request is invalid - grant_type must be password or refresh_token, email and password must be provided for grant_type=password, refresh_token must be provided for grant_type=refresh_token")
+	ErrInvalidAuthorizeRequest         = errors.New("authorize request is invalid - provider must be provided")
+	ErrInvalidLinkIdentityRequest      = errors.New("link identity request is invalid - provider must be provided")
+	ErrInvalidTokenRequest             = errors.New("token request is invalid - grant_type must be password, refresh_token, pkce or id_token, email and password must be provided for grant_type=password, refresh_token must be provided for grant_type=refresh_token, code and code_verifier must be provided for grant_type=pkce, provider and id_token must be provided for grant_type=id_token")
 	ErrInvalidVerifyRequest            = errors.New("verify request is invalid - type, token and redirect_to must be provided, and email or phone must be provided to VerifyForUser")
 )
 
@@ -117,8 +121,40 @@ type AdminCreateUserResponse struct {
 	User
 }
 
+type SortDirection string
+
+const (
+	SortAscending  SortDirection = "asc"
+	SortDescending SortDirection = "desc"
+)
+
+type UserSort struct {
+	// Column is the user column to sort by, e.g. "created_at" or "email".
+	Column    string
+	Direction SortDirection
+}
+
+type AdminListUsersRequest struct {
+	// Filter, if provided, only returns users whose email, or name in their
+	// user metadata, contains the given value.
+	Filter string
+	// Sort, if provided, sets the order users are returned in. By default,
+	// users are sorted by created_at, newest first.
+	Sort *UserSort
+
+	// Pagination
+	Page    uint
+	PerPage uint
+}
+
 type AdminListUsersResponse struct {
 	Users []User `json:"users"`
+	Aud   string `json:"aud"`
+
+	// Pagination
+	TotalCount int  `json:"-"`
+	TotalPages uint `json:"-"`
+	NextPage   uint `json:"-"`
 }
 
 type AdminGetUserRequest struct {
@@ -176,6 +212,32 @@ type AdminDeleteUserFactorRequest struct {
 	FactorID uuid.UUID
 }
 
+type AdminDeleteUserFactorsRequest struct {
+	UserID uuid.UUID
+
+	// FactorTypes limits deletion to factors of the given types. Factors of
+	// every type are deleted if empty.
+	FactorTypes []FactorType
+	// Statuses limits deletion to factors with the given statuses, e.g.
+	// FactorStatusUnverified. Factors with any status are deleted if empty.
+	Statuses []string
+}
+
+type AdminDeleteUserFactorsResponse struct {
+	UserID uuid.UUID
+	// Deleted lists the factors that were deleted, as they were before
+	// deletion.
+	Deleted []Factor
+}
+
+type AdminSignOutRequest struct {
+	// AccessToken is an access token of the user to sign out. Required.
+	AccessToken string
+	// Scope defaults to LogoutScopeGlobal, which revokes all of the user's
+	// sessions.
+	Scope LogoutScope
+}
+
 type SAMLAttribute struct {
 	Name    string      `json:"name,omitempty"`
 	Names   []string    `json:"names,omitempty"`
@@ -287,6 +349,24 @@ type AuthorizeRequest struct {
 	Provider Provider
 	FlowType FlowType
 	Scopes   string
+
+	// RedirectTo is where the user is sent after signing in with the
+	// provider. It must be allowed by the server's redirect URL settings.
+	// Defaults to the server's site URL.
+	RedirectTo string
+
+	// QueryParams are passed on to the provider's authorization endpoint,
+	// e.g. {"access_type": "offline", "prompt": "consent"} for Google.
+	QueryParams map[string]string
+
+	// SkipHTTPRedirect, if true, builds the URL of the server's authorize
+	// endpoint locally instead of requesting it. The returned
+	// AuthorizationURL then points to the server, which redirects the user to
+	// the provider when visited.
+	//
+	// This saves a round trip, but errors such as a disabled provider are
+	// only reported once the user visits the URL.
+	SkipHTTPRedirect bool
 }
 
 type AuthorizeResponse struct {
@@ -294,6 +374,31 @@ type AuthorizeResponse struct {
 	Verifier         string
 }
 
+type GetUserIdentitiesResponse struct {
+	Identities []Identity
+}
+
+type LinkIdentityRequest struct {
+	Provider Provider
+	FlowType FlowType
+	Scopes   string
+
+	// RedirectTo is where the user is sent after signing in with the
+	// provider. It must be allowed by the server's redirect URL settings.
+	// Defaults to the server's site URL.
+	RedirectTo string
+
+	// QueryParams are passed on to the provider's authorization endpoint.
+	QueryParams map[string]string
+}
+
+type LinkIdentityResponse struct {
+	AuthorizationURL string
+	// Verifier is set if FlowType is FlowPKCE. It must be passed to
+	// ExchangeCodeForSession with the code returned to RedirectTo.
+	Verifier string
+}
+
 // adapted from https://go-review.googlesource.com/c/oauth2/+/463979/9/pkce.go#64
 type PKCEParams struct {
 	Challenge       string
@@ -303,12 +408,27 @@ type PKCEParams struct {
 
 type FactorType string
 
-const FactorTypeTOTP FactorType = "totp"
+const (
+	FactorTypeTOTP  FactorType = "totp"
+	FactorTypePhone FactorType = "phone"
+)
+
+// MFAChannel is how the code for a phone factor is sent.
+type MFAChannel string
+
+const (
+	MFAChannelSMS      MFAChannel = "sms"
+	MFAChannelWhatsApp MFAChannel = "whatsapp"
+)
 
 type EnrollFactorRequest struct {
 	FriendlyName string     `json:"friendly_name"`
 	FactorType   FactorType `json:"factor_type"`
-	Issuer       string     `json:"issuer"`
+	// Issuer is only used for TOTP factors.
+	Issuer string `json:"issuer"`
+	// Phone is the phone number to send codes to. Required for phone
+	// factors.
+	Phone string `json:"phone,omitempty"`
 }
 
 type TOTPObject struct {
@@ -320,16 +440,23 @@ type TOTPObject struct {
 type EnrollFactorResponse struct {
 	ID   uuid.UUID  `json:"id"`
 	Type FactorType `json:"type"`
+	// TOTP is only set for TOTP factors.
 	TOTP TOTPObject `json:"totp,omitempty"`
+	// Phone is only set for phone factors.
+	Phone string `json:"phone,omitempty"`
 }
 
 type ChallengeFactorRequest struct {
 	FactorID uuid.UUID `json:"factor_id"`
+	// Channel is how the code is sent for phone factors. Defaults to SMS.
+	// Ignored for TOTP factors.
+	Channel MFAChannel `json:"channel,omitempty"`
 }
 
 type ChallengeFactorResponse struct {
-	ID        uuid.UUID `json:"id"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ID        uuid.UUID  `json:"id"`
+	Type      FactorType `json:"type"`
+	ExpiresAt time.Time  `json:"expires_at"`
 }
 
 type VerifyFactorRequest struct {
@@ -343,6 +470,29 @@ type VerifyFactorResponse struct {
 	Session
 }
 
+type AuthenticatorAssuranceLevelResponse struct {
+	// CurrentLevel is the AAL of the access token.
+	CurrentLevel AAL
+	// NextLevel is the highest AAL the user can reach in this session: AAL2
+	// if they have a verified factor, or CurrentLevel otherwise. If it is
+	// higher than CurrentLevel, prompt the user to verify a factor.
+	NextLevel AAL
+	// CurrentAuthenticationMethods are the methods used to obtain the
+	// session, from the access token's "amr" claim.
+	CurrentAuthenticationMethods []AMREntry
+}
+
+// ListFactorsResponse is the user's factors, split in a few ways for
+// convenience. Each factor is in All, and in one of Verified or Unverified.
+// TOTP and Phone hold the factors of each type, whatever their status.
+type ListFactorsResponse struct {
+	All        []Factor
+	Verified   []Factor
+	Unverified []Factor
+	TOTP       []Factor
+	Phone      []Factor
+}
+
 type UnenrollFactorRequest struct {
 	FactorID uuid.UUID
 }
@@ -366,10 +516,28 @@ type InviteResponse struct {
 	User
 }
 
+// LogoutScope selects which of the user's sessions are revoked by a logout.
+type LogoutScope string
+
+const (
+	// Revoke all of the user's sessions. This is the default.
+	LogoutScopeGlobal LogoutScope = "global"
+	// Revoke only the current session.
+	LogoutScopeLocal LogoutScope = "local"
+	// Revoke all of the user's sessions except the current one.
+	LogoutScopeOthers LogoutScope = "others"
+)
+
 // DEPRECATED: Use /otp with Email and CreateUser=true instead of /magiclink.
 type MagiclinkRequest struct {
 	Email string `json:"email"`
 
+	// CodeChallenge and CodeChallengeMethod enable the PKCE flow, so that the
+	// link in the email redirects with a code to exchange for a session
+	// instead of tokens in the URL fragment. See GeneratePKCEParams.
+	CodeChallenge       string `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty"`
+
 	// Provide Captcha token if enabled.
 	SecurityEmbed
 }
@@ -380,6 +548,12 @@ type OTPRequest struct {
 	CreateUser bool                   `json:"create_user"`
 	Data       map[string]interface{} `json:"data"`
 
+	// CodeChallenge and CodeChallengeMethod enable the PKCE flow, so that the
+	// link in the email redirects with a code to exchange for a session
+	// instead of tokens in the URL fragment. See GeneratePKCEParams.
+	CodeChallenge       string `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty"`
+
 	// Provide Captcha token if enabled.
 	SecurityEmbed
 }
@@ -387,11 +561,20 @@ type OTPRequest struct {
 type RecoverRequest struct {
 	Email string `json:"email"`
 
+	// CodeChallenge and CodeChallengeMethod enable the PKCE flow, so that the
+	// link in the email redirects with a code to exchange for a session
+	// instead of tokens in the URL fragment. See GeneratePKCEParams.
+	CodeChallenge       string `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty"`
+
 	// Provide Captcha token if enabled.
 	SecurityEmbed
 }
 
 type ExternalProviders struct {
+	// AnonymousUsers is true if SignInAnonymously is enabled.
+	AnonymousUsers bool `json:"anonymous_users"`
+
 	Apple     bool `json:"apple"`
 	Azure     bool `json:"azure"`
 	Bitbucket bool `json:"bitbucket"`
@@ -430,10 +613,28 @@ type SignupRequest struct {
 	Password string                 `json:"password,omitempty"`
 	Data     map[string]interface{} `json:"data,omitempty"`
 
+	// CodeChallenge and CodeChallengeMethod enable the PKCE flow, so that the
+	// link in the email redirects with a code to exchange for a session
+	// instead of tokens in the URL fragment. See GeneratePKCEParams.
+	CodeChallenge       string `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty"`
+
 	// Provide Captcha token if enabled.
 	SecurityEmbed
 }
 
+type SignInAnonymouslyRequest struct {
+	// Data is stored as the anonymous user's user_metadata.
+	Data map[string]interface{} `json:"data,omitempty"`
+
+	// Provide Captcha token if enabled.
+	SecurityEmbed
+}
+
+type SignInAnonymouslyResponse struct {
+	Session
+}
+
 type SignupResponse struct {
 	// Response if autoconfirm is off
 	User
@@ -475,11 +676,25 @@ type TokenRequest struct {
 	RefreshToken string `json:"refresh_token,omitempty"`
 
 	// Code and CodeVerifier are required if GrantType is 'pkce'.
-	Code string `json:"code,omitempty"`
+	Code string `json:"auth_code,omitempty"`
 
 	// Code and CodeVerifier are required if GrantType is 'pkce'.
 	CodeVerifier string `json:"code_verifier,omitempty"`
 
+	// Provider and IDToken are required if GrantType is 'id_token'. Provider
+	// is the issuer of the ID token, e.g. ProviderApple or ProviderGoogle, and
+	// IDToken is the OpenID Connect ID token returned by its native sign-in
+	// SDK.
+	Provider Provider `json:"provider,omitempty"`
+	IDToken  string   `json:"id_token,omitempty"`
+	// AccessToken is the provider's access token, if one was issued together
+	// with the ID token. Required if the ID token has an at_hash claim.
+	AccessToken string `json:"access_token,omitempty"`
+	// Nonce is the raw nonce passed to the provider's sign-in SDK, if any.
+	// Some providers, such as Apple, only include its SHA-256 hash in the ID
+	// token; GoTrue hashes it before comparing.
+	Nonce string `json:"nonce,omitempty"`
+
 	// Provide Captcha token if enabled. Not required if GrantType is 'refresh_token'.
 	SecurityEmbed
 }
@@ -526,6 +741,11 @@ type VerifyRequest struct {
 type VerifyResponse struct {
 	URL string
 
+	// Code is returned instead of the tokens below if the flow was started
+	// with a PKCE code challenge. Exchange it for a session with
+	// ExchangeCodeForSession.
+	Code string
+
 	// The fields below are returned only for a successful response.
 	AccessToken  string
 	TokenType    string
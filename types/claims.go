@@ -0,0 +1,149 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidJWT = errors.New("invalid JWT")
+
+// AAL is an authenticator assurance level.
+type AAL string
+
+const (
+	// The user has signed in with a single factor, e.g. a password.
+	AAL1 AAL = "aal1"
+	// The user has additionally verified a second factor, e.g. TOTP.
+	AAL2 AAL = "aal2"
+)
+
+// AMREntry is an authentication method used to obtain a session, as found in
+// the "amr" claim.
+type AMREntry struct {
+	// The method, e.g. "password", "otp", "totp" or "oauth".
+	Method string `json:"method"`
+	// The Unix time at which the method was used.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Claims are the claims of an access token issued by GoTrue.
+type Claims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  ClaimStrings `json:"aud,omitempty"`
+	Expiry    int64        `json:"exp,omitempty"`
+	NotBefore int64        `json:"nbf,omitempty"`
+	IssuedAt  int64        `json:"iat,omitempty"`
+
+	Email        string                 `json:"email,omitempty"`
+	Phone        string                 `json:"phone,omitempty"`
+	Role         string                 `json:"role,omitempty"`
+	AAL          AAL                    `json:"aal,omitempty"`
+	AMR          []AMREntry             `json:"amr,omitempty"`
+	SessionID    string                 `json:"session_id,omitempty"`
+	AppMetadata  map[string]interface{} `json:"app_metadata,omitempty"`
+	UserMetadata map[string]interface{} `json:"user_metadata,omitempty"`
+	IsAnonymous  bool                   `json:"is_anonymous,omitempty"`
+}
+
+// Decode the claims of an access token without verifying its signature or
+// checking that it has not expired.
+//
+// The claims are only trustworthy if the token came from GoTrue directly, e.g.
+// in a Session, or has been validated, e.g. with GetUser or the verifier
+// package. Don't use them to authorize requests otherwise.
+func ParseClaims(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrInvalidJWT, len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJWT, err)
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJWT, err)
+	}
+	return &claims, nil
+}
+
+// Get the time at which the token expires, from the "exp" claim. Returns the
+// zero time if the claim is missing.
+func (c *Claims) ExpiresAt() time.Time {
+	if c.Expiry == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.Expiry, 0)
+}
+
+// Check whether the token has expired, or will within skew. Tokens without an
+// "exp" claim are treated as expired.
+func (c *Claims) IsExpired(skew time.Duration) bool {
+	if c.Expiry == 0 {
+		return true
+	}
+	return !time.Now().Add(skew).Before(c.ExpiresAt())
+}
+
+// Parse the "session_id" claim. Returns uuid.Nil if the claim is missing, e.g.
+// in tokens issued by older servers or signed manually.
+func (c *Claims) SessionUUID() (uuid.UUID, error) {
+	if c.SessionID == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(c.SessionID)
+}
+
+// ClaimStrings is a claim that may be either a single string or an array of
+// strings, such as "aud".
+type ClaimStrings []string
+
+func (s ClaimStrings) Contains(v string) bool {
+	for _, c := range s {
+		if c == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (s ClaimStrings) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]string(s))
+}
+
+func (s *ClaimStrings) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		*s = nil
+	case string:
+		*s = ClaimStrings{v}
+	case []interface{}:
+		strs := make(ClaimStrings, 0, len(v))
+		for _, e := range v {
+			str, ok := e.(string)
+			if !ok {
+				return errors.New("claim must be a string or an array of strings")
+			}
+			strs = append(strs, str)
+		}
+		*s = strs
+	default:
+		return errors.New("claim must be a string or an array of strings")
+	}
+	return nil
+}
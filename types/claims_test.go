@@ -0,0 +1,83 @@
+package types_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+// Build an unsigned token with the given payload. The signature is not
+// checked when parsing claims.
+func token(t *testing.T, payload map[string]interface{}) string {
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9." + base64.RawURLEncoding.EncodeToString(data) + ".c2lnbmF0dXJl"
+}
+
+func TestParseClaims(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sessionID := uuid.New()
+	exp := time.Now().Add(time.Hour).Unix()
+	session := types.Session{
+		AccessToken: token(t, map[string]interface{}{
+			"sub":          "8a5ef1b4-7ef1-4bd6-9a33-2a0b1d1c0b2e",
+			"aud":          "authenticated",
+			"exp":          exp,
+			"role":         "authenticated",
+			"aal":          "aal2",
+			"amr":          []map[string]interface{}{{"method": "totp", "timestamp": 1700000000}},
+			"session_id":   sessionID.String(),
+			"app_metadata": map[string]interface{}{"provider": "email"},
+			"is_anonymous": false,
+		}),
+	}
+
+	claims, err := session.Claims()
+	require.NoError(err)
+	assert.Equal("authenticated", claims.Role)
+	assert.Equal(types.AAL2, claims.AAL)
+	assert.Equal([]types.AMREntry{{Method: "totp", Timestamp: 1700000000}}, claims.AMR)
+	assert.Equal("email", claims.AppMetadata["provider"])
+	assert.True(claims.Audience.Contains("authenticated"))
+	assert.Equal(time.Unix(exp, 0), claims.ExpiresAt())
+	assert.False(claims.IsExpired(0))
+	assert.False(claims.IsExpired(59 * time.Minute))
+	assert.True(claims.IsExpired(2 * time.Hour))
+
+	id, err := session.SessionID()
+	require.NoError(err)
+	assert.Equal(sessionID, id)
+
+	// Expired, and without a session ID
+	claims, err = types.ParseClaims(token(t, map[string]interface{}{
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	}))
+	require.NoError(err)
+	assert.True(claims.IsExpired(0))
+	id, err = claims.SessionUUID()
+	require.NoError(err)
+	assert.Equal(uuid.Nil, id)
+
+	// No expiry
+	claims, err = types.ParseClaims(token(t, map[string]interface{}{}))
+	require.NoError(err)
+	assert.True(claims.ExpiresAt().IsZero())
+	assert.True(claims.IsExpired(0))
+
+	// Invalid tokens
+	for _, tok := range []string{"", "opaque", "a.b", "a.!!!.c", "a." + base64.RawURLEncoding.EncodeToString([]byte("[]")) + ".c"} {
+		_, err := types.ParseClaims(tok)
+		assert.ErrorIs(err, types.ErrInvalidJWT, tok)
+	}
+	_, err = types.Session{}.SessionID()
+	assert.ErrorIs(err, types.ErrInvalidJWT)
+}
@@ -0,0 +1,204 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrorCode is a machine readable error code returned by the GoTrue server.
+//
+// The list below is not exhaustive. See the GoTrue source for the full list of
+// error codes.
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidCredentials     ErrorCode = "invalid_credentials"
+	ErrorCodeEmailNotConfirmed      ErrorCode = "email_not_confirmed"
+	ErrorCodePhoneNotConfirmed      ErrorCode = "phone_not_confirmed"
+	ErrorCodeUserAlreadyExists      ErrorCode = "user_already_exists"
+	ErrorCodeEmailExists            ErrorCode = "email_exists"
+	ErrorCodePhoneExists            ErrorCode = "phone_exists"
+	ErrorCodeWeakPassword           ErrorCode = "weak_password"
+	ErrorCodeOverRequestRateLimit   ErrorCode = "over_request_rate_limit"
+	ErrorCodeOverEmailSendRateLimit ErrorCode = "over_email_send_rate_limit"
+	ErrorCodeOverSMSSendRateLimit   ErrorCode = "over_sms_send_rate_limit"
+	ErrorCodeUserNotFound           ErrorCode = "user_not_found"
+	ErrorCodeSessionNotFound        ErrorCode = "session_not_found"
+	ErrorCodeValidationFailed       ErrorCode = "validation_failed"
+	ErrorCodeMFAChallengeExpired    ErrorCode = "mfa_challenge_expired"
+	ErrorCodeMFAFactorNotFound      ErrorCode = "mfa_factor_not_found"
+	ErrorCodeIdentityAlreadyExists  ErrorCode = "identity_already_exists"
+	ErrorCodeIdentityNotFound       ErrorCode = "identity_not_found"
+	ErrorCodeManualLinkingDisabled  ErrorCode = "manual_linking_disabled"
+
+	ErrorCodeSingleIdentityNotDeletable        ErrorCode = "single_identity_not_deletable"
+	ErrorCodeEmailConflictIdentityNotDeletable ErrorCode = "email_conflict_identity_not_deletable"
+)
+
+// APIError is returned by client methods when the GoTrue server responds with
+// a non-success status code.
+//
+// Use errors.As to access the details of the error:
+//
+//	var apiErr *types.APIError
+//	if errors.As(err, &apiErr) {
+//		log.Println(apiErr.StatusCode, apiErr.ErrorCode, apiErr.Message)
+//	}
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// RequestID is the value of the request ID header of the response, if any.
+	RequestID string
+
+	// Code is the "code" field of the response body. Older versions of GoTrue
+	// set this to the HTTP status code. Newer versions send the error code as
+	// a string instead, in which case Code is 0 and the string is stored in
+	// ErrorCode.
+	Code int
+	// ErrorCode is the machine readable error code, if the server provided one.
+	ErrorCode ErrorCode
+	// Message is the human readable error message. GoTrue returns this in one
+	// of the msg, message or error_description fields depending on the
+	// endpoint; the first non-empty one is used.
+	Message string
+	// OAuthError is the "error" field returned by OAuth2 style endpoints such
+	// as POST /token, e.g. "invalid_grant".
+	OAuthError string
+	// WeakPassword is populated when the server rejected a password for being
+	// too weak.
+	WeakPassword *WeakPasswordDetails
+
+	// Body is the raw response body.
+	Body []byte
+}
+
+type WeakPasswordDetails struct {
+	Reasons []string `json:"reasons"`
+}
+
+func (e *APIError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("response status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("response status code %d: %s", e.StatusCode, e.Body)
+}
+
+// RateLimitError is returned when a request was rejected because of a rate
+// limit, either by the server or by a client-side limiter such as
+// gotrue.SendLimiter.
+//
+//	var rlErr *types.RateLimitError
+//	if errors.As(err, &rlErr) && rlErr.RetryAfter > 0 {
+//		log.Printf("try again in %d seconds", int(rlErr.RetryAfter.Seconds()))
+//	}
+type RateLimitError struct {
+	// RetryAfter is how long to wait before trying again, if known. It is
+	// taken from the Retry-After header of the response, or from the error
+	// message for limits that GoTrue only reports there.
+	RetryAfter time.Duration
+	// APIError is the response from the server. It is nil if the request was
+	// rejected by a client-side limiter without being sent.
+	APIError *APIError
+}
+
+func (e *RateLimitError) Error() string {
+	if e.APIError != nil {
+		return e.APIError.Error()
+	}
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limit exceeded, try again in %s", e.RetryAfter)
+	}
+	return "rate limit exceeded"
+}
+
+func (e *RateLimitError) Unwrap() error {
+	if e.APIError == nil {
+		return nil
+	}
+	return e.APIError
+}
+
+// Check if the error is an *APIError with the given error code.
+func HasErrorCode(err error, code ErrorCode) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.ErrorCode == code
+}
+
+// Check if the error is the result of hitting one of the server's rate limits,
+// or a client-side limiter.
+func IsRateLimited(err error) bool {
+	var rlErr *RateLimitError
+	if errors.As(err, &rlErr) {
+		return true
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode {
+	case ErrorCodeOverRequestRateLimit, ErrorCodeOverEmailSendRateLimit, ErrorCodeOverSMSSendRateLimit:
+		return true
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// Check if the error is the result of signing in with an incorrect email,
+// phone or password.
+func IsInvalidCredentials(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.ErrorCode == ErrorCodeInvalidCredentials {
+		return true
+	}
+	// Older servers only return an OAuth2 style error.
+	return apiErr.OAuthError == "invalid_grant" && apiErr.Message == "Invalid login credentials"
+}
+
+// Check if the error is the result of signing up, or creating a user, with an
+// email or phone that is already registered.
+func IsUserAlreadyExists(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode {
+	case ErrorCodeUserAlreadyExists, ErrorCodeEmailExists, ErrorCodePhoneExists:
+		return true
+	}
+	// Older servers only return a message.
+	return apiErr.ErrorCode == "" && apiErr.Message == "User already registered"
+}
+
+// Check if the error is the result of the server rejecting a password for
+// being too weak. The reasons, if given, are available in the WeakPassword
+// field of the *APIError.
+func IsWeakPassword(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.ErrorCode == ErrorCodeWeakPassword || apiErr.WeakPassword != nil
+}
+
+// Check if the error is the result of trying to unlink an identity that can't
+// be unlinked: either the user's only identity, or one whose email the user
+// would be left without.
+func IsIdentityNotDeletable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode {
+	case ErrorCodeSingleIdentityNotDeletable, ErrorCodeEmailConflictIdentityNotDeletable:
+		return true
+	}
+	// Older servers only return a message.
+	return apiErr.ErrorCode == "" && apiErr.Message == "User must have at least 1 identity after unlinking"
+}
@@ -0,0 +1,101 @@
+package types_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestAPIError(t *testing.T) {
+	assert := assert.New(t)
+
+	err := &types.APIError{
+		StatusCode: http.StatusBadRequest,
+		Body:       []byte(`{"error":"invalid_grant"}`),
+	}
+	assert.Equal(`response status code 400: {"error":"invalid_grant"}`, err.Error())
+	assert.Equal("response status code 500", (&types.APIError{StatusCode: 500}).Error())
+
+	// Helpers should see through wrapped errors.
+	wrapped := fmt.Errorf("signing in: %w", &types.APIError{
+		StatusCode: http.StatusBadRequest,
+		ErrorCode:  types.ErrorCodeInvalidCredentials,
+	})
+	assert.True(types.IsInvalidCredentials(wrapped))
+	assert.True(types.HasErrorCode(wrapped, types.ErrorCodeInvalidCredentials))
+	assert.False(types.IsRateLimited(wrapped))
+	assert.False(types.IsInvalidCredentials(fmt.Errorf("some other error")))
+
+	tests := map[string]struct {
+		err   *types.APIError
+		check func(error) bool
+	}{
+		"invalid_credentials/oauth": {
+			err:   &types.APIError{OAuthError: "invalid_grant", Message: "Invalid login credentials"},
+			check: types.IsInvalidCredentials,
+		},
+		"rate_limited/status": {
+			err:   &types.APIError{StatusCode: http.StatusTooManyRequests},
+			check: types.IsRateLimited,
+		},
+		"rate_limited/code": {
+			err:   &types.APIError{StatusCode: http.StatusBadRequest, ErrorCode: types.ErrorCodeOverEmailSendRateLimit},
+			check: types.IsRateLimited,
+		},
+		"user_already_exists/code": {
+			err:   &types.APIError{ErrorCode: types.ErrorCodeEmailExists},
+			check: types.IsUserAlreadyExists,
+		},
+		"user_already_exists/message": {
+			err:   &types.APIError{Message: "User already registered"},
+			check: types.IsUserAlreadyExists,
+		},
+		"weak_password/code": {
+			err:   &types.APIError{ErrorCode: types.ErrorCodeWeakPassword},
+			check: types.IsWeakPassword,
+		},
+		"weak_password/details": {
+			err:   &types.APIError{WeakPassword: &types.WeakPasswordDetails{Reasons: []string{"length"}}},
+			check: types.IsWeakPassword,
+		},
+		"single_identity_not_deletable": {
+			err:   &types.APIError{ErrorCode: types.ErrorCodeSingleIdentityNotDeletable},
+			check: types.IsIdentityNotDeletable,
+		},
+		"email_conflict_identity_not_deletable": {
+			err:   &types.APIError{ErrorCode: types.ErrorCodeEmailConflictIdentityNotDeletable},
+			check: types.IsIdentityNotDeletable,
+		},
+		"single_identity_not_deletable/legacy": {
+			err:   &types.APIError{Message: "User must have at least 1 identity after unlinking"},
+			check: types.IsIdentityNotDeletable,
+		},
+	}
+	for name, test := range tests {
+		assert.True(test.check(test.err), name)
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	assert := assert.New(t)
+
+	// Client-side limits have no response.
+	err := &types.RateLimitError{RetryAfter: 30 * time.Second}
+	assert.Equal("rate limit exceeded, try again in 30s", err.Error())
+	assert.True(types.IsRateLimited(err))
+	assert.Nil(errors.Unwrap(err))
+
+	apiErr := &types.APIError{StatusCode: http.StatusTooManyRequests}
+	err = &types.RateLimitError{APIError: apiErr}
+	assert.Equal(apiErr.Error(), err.Error())
+	assert.True(types.IsRateLimited(fmt.Errorf("sending otp: %w", err)))
+	var target *types.APIError
+	assert.ErrorAs(err, &target)
+	assert.Same(apiErr, target)
+}
@@ -6,6 +6,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// Factor statuses, as found in Factor.Status.
+const (
+	FactorStatusUnverified = "unverified"
+	FactorStatusVerified   = "verified"
+)
+
 type Factor struct {
 	ID           uuid.UUID `json:"id"`
 	CreatedAt    time.Time `json:"created_at"`
@@ -13,4 +19,40 @@ type Factor struct {
 	Status       string    `json:"status"`
 	FriendlyName string    `json:"friendly_name,omitempty"`
 	FactorType   string    `json:"factor_type"`
+	// Phone is only set for phone factors.
+	Phone string `json:"phone,omitempty"`
+}
+
+// Filter returns the factors that have one of factorTypes and one of statuses.
+// An empty list matches every factor.
+func (r *AdminListUserFactorsResponse) Filter(factorTypes []FactorType, statuses []string) []Factor {
+	var factors []Factor
+	for _, f := range r.Factors {
+		if len(factorTypes) > 0 && !containsFactorType(factorTypes, FactorType(f.FactorType)) {
+			continue
+		}
+		if len(statuses) > 0 && !containsString(statuses, f.Status) {
+			continue
+		}
+		factors = append(factors, f)
+	}
+	return factors
+}
+
+func containsFactorType(s []FactorType, v FactorType) bool {
+	for _, t := range s {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(s []string, v string) bool {
+	for _, t := range s {
+		if t == v {
+			return true
+		}
+	}
+	return false
 }
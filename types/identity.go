@@ -7,7 +7,11 @@ import (
 )
 
 type Identity struct {
-	ID           string                 `json:"id"`
+	// ID is the user's ID at the provider.
+	ID string `json:"id"`
+	// IdentityID is the unique ID of the identity, used by UnlinkIdentity.
+	// Only returned by newer servers.
+	IdentityID   uuid.UUID              `json:"identity_id"`
 	UserID       uuid.UUID              `json:"user_id"`
 	IdentityData map[string]interface{} `json:"identity_data,omitempty"`
 	Provider     string                 `json:"provider"`
@@ -0,0 +1,87 @@
+package types
+
+import (
+	"context"
+	"net/http"
+)
+
+// Middleware wraps the transport used to send requests to the GoTrue server,
+// e.g. to add headers, log requests or record metrics. The returned
+// http.RoundTripper sees every request the client sends, including retries.
+//
+// As with any http.RoundTripper, the returned transport must not modify the
+// request it is given. Clone it first to change headers.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface, for
+// writing Middleware:
+//
+//	func logRequests(next http.RoundTripper) http.RoundTripper {
+//		return types.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+//			resp, err := next.RoundTrip(r)
+//			log.Println(r.Method, r.URL.Path, err)
+//			return resp, err
+//		})
+//	}
+type RoundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// HeaderFunc sets headers on a request before it is sent. ctx is the context
+// of the request, as set with WithContext, so the headers can depend on e.g.
+// the inbound request being handled.
+type HeaderFunc func(ctx context.Context, header http.Header)
+
+// SetHeaders returns Middleware that calls fn to set headers on every request,
+// e.g. to forward the IP address of an end user so that GoTrue's IP based
+// rate limits apply to them rather than to the server:
+//
+//	client.WithMiddleware(types.SetHeaders(func(ctx context.Context, h http.Header) {
+//		if ip, ok := ctx.Value(clientIPKey).(string); ok {
+//			h.Set("X-Forwarded-For", ip)
+//		}
+//	}))
+//
+// fn is called after the client has set its own headers, so it can replace
+// them.
+func SetHeaders(fn HeaderFunc) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			r = r.Clone(r.Context())
+			fn(r.Context(), r.Header)
+			return next.RoundTrip(r)
+		})
+	}
+}
+
+// OperationHook is called when a client method starts, with the name of the
+// method, e.g. "Token" or "AdminGetUser", and the context set with
+// WithContext. The returned context is used for the method's requests, so
+// Middleware can find values stored in it, such as a span. end is called when
+// the method returns, with its error if it failed.
+type OperationHook func(ctx context.Context, operation string) (_ context.Context, end func(err error))
+
+type operationKey struct{}
+
+// Return a copy of ctx carrying the name of the client method making a
+// request. The client sets it on the context of every request it sends.
+func ContextWithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// Get the name of the client method that made a request, e.g. "Token" or
+// "AdminGetUser", from the request's context. Middleware can use it to name
+// spans, metrics or log entries:
+//
+//	op, _ := types.OperationFromContext(r.Context())
+//
+// Requests sent by methods that are built on other methods report the outer
+// method, e.g. the request sent by ListFactors reports "ListFactors" rather
+// than "GetUser". Convenience methods that fill in a request for another
+// method report that method, e.g. SignInWithEmailPassword reports "Token".
+func OperationFromContext(ctx context.Context) (string, bool) {
+	op, ok := ctx.Value(operationKey{}).(string)
+	return op, ok && op != ""
+}
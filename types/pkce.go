@@ -0,0 +1,33 @@
+package types
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+)
+
+// Generate a random code verifier and its S256 code challenge for the PKCE
+// flow.
+//
+// Send Challenge and ChallengeMethod with the request that starts the flow,
+// e.g. as CodeChallenge and CodeChallengeMethod of an OTPRequest, and keep
+// Verifier somewhere only the client can read it, e.g. in a cookie. Once the
+// user is redirected back with a code, exchange it for a session with
+// ExchangeCodeForSession(code, Verifier).
+func GeneratePKCEParams() (*PKCEParams, error) {
+	data := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, data); err != nil {
+		return nil, err
+	}
+
+	// RawURLEncoding since "code challenge can only contain alphanumeric characters, hyphens, periods, underscores and tildes"
+	verifier := base64.RawURLEncoding.EncodeToString(data)
+	sha := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sha[:])
+	return &PKCEParams{
+		Challenge:       challenge,
+		ChallengeMethod: "S256",
+		Verifier:        verifier,
+	}, nil
+}
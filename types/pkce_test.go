@@ -0,0 +1,31 @@
+package types_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+func TestGeneratePKCEParams(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p, err := types.GeneratePKCEParams()
+	require.NoError(err)
+	assert.Equal("S256", p.ChallengeMethod)
+	// 32 random bytes, base64url encoded without padding.
+	assert.Len(p.Verifier, 43)
+	assert.Regexp(`^[A-Za-z0-9_-]+$`, p.Verifier)
+
+	sha := sha256.Sum256([]byte(p.Verifier))
+	assert.Equal(base64.RawURLEncoding.EncodeToString(sha[:]), p.Challenge)
+
+	other, err := types.GeneratePKCEParams()
+	require.NoError(err)
+	assert.NotEqual(p.Verifier, other.Verifier)
+}
@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+// RetryPolicy controls how requests that fail with a transient error are
+// retried. The zero value disables retries.
+//
+// A request is retried if it could not be sent at all, e.g. because the
+// connection was refused, or if the server responded with 429 Too Many
+// Requests, 502 Bad Gateway, 503 Service Unavailable or 504 Gateway Timeout.
+//
+// Between attempts, the client waits for an exponentially increasing backoff
+// with random jitter. If a 429 or 503 response has a Retry-After header, the
+// client waits at least that long instead. If Retry-After asks the client to
+// wait longer than MaxBackoff, the request is not retried and the response is
+// returned as is.
+//
+// The timeout of the HTTP client applies to each attempt separately. Use
+// WithContext to bound the total time spent on a request.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, including
+	// the first attempt. Values less than 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the base wait before the first retry. It doubles after
+	// each attempt. Defaults to 200 milliseconds.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. Defaults to 5 seconds.
+	MaxBackoff time.Duration
+
+	// RetryNonIdempotent enables retries for requests that change state on
+	// the server, such as Signup, Token or AdminCreateUser. By default, only
+	// requests that are safe to repeat, such as GetUser, AdminGetUser,
+	// GetSettings and HealthCheck, are retried.
+	//
+	// A request that fails because the connection dropped may still have been
+	// processed by the server, so retrying it may e.g. send a second email or
+	// fail because a one-time token has already been used. Only enable this
+	// for calls where that is acceptable.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy retries idempotent requests up to 3 times in total.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/google/uuid"
+)
+
 type Session struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
@@ -8,3 +12,19 @@ type Session struct {
 	ExpiresAt    int64  `json:"expires_at"`
 	User         User   `json:"user"`
 }
+
+// Decode the claims of the session's access token. See ParseClaims.
+func (s Session) Claims() (*Claims, error) {
+	return ParseClaims(s.AccessToken)
+}
+
+// Get the ID of the session, from the "session_id" claim of its access token.
+// It identifies the session on the server, e.g. in the audit log, and stays
+// the same when the session is refreshed.
+func (s Session) SessionID() (uuid.UUID, error) {
+	claims, err := s.Claims()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.SessionUUID()
+}
@@ -35,6 +35,10 @@ type User struct {
 	AppMetadata  map[string]interface{} `json:"app_metadata"`
 	UserMetadata map[string]interface{} `json:"user_metadata"`
 
+	// IsAnonymous is true for users created with SignInAnonymously, until
+	// they are converted into a permanent user with UpdateUser.
+	IsAnonymous bool `json:"is_anonymous"`
+
 	Factors    []Factor   `json:"factors,omitempty"`
 	Identities []Identity `json:"identities"`
 
@@ -0,0 +1,247 @@
+package verifier
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type cachedKey struct {
+	alg string
+	key interface{}
+}
+
+// fetchTimeout bounds each fetch of the JWKS, which runs detached from the
+// context of the token being verified.
+const fetchTimeout = 10 * time.Second
+
+// jwksCache fetches and caches the public keys served by GoTrue.
+type jwksCache struct {
+	url                string
+	client             *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]cachedKey
+	fetchedAt time.Time
+	// failedAt and fetchErr are set when the last fetch failed.
+	failedAt time.Time
+	fetchErr error
+	inflight *jwksFetch
+}
+
+type jwksFetch struct {
+	done chan struct{}
+}
+
+func newJWKSCache(url string, client *http.Client, ttl, minRefreshInterval time.Duration) *jwksCache {
+	return &jwksCache{
+		url:                url,
+		client:             client,
+		ttl:                ttl,
+		minRefreshInterval: minRefreshInterval,
+	}
+}
+
+// Get the key with the given ID for verifying a token signed with alg,
+// fetching the JWKS if the cache does not contain the key.
+//
+// If the cache has expired but contains the key, the cached key is used while
+// the JWKS is fetched again in the background. Only one fetch is in flight at
+// a time; callers that need its result wait for it, or until ctx is done.
+func (c *jwksCache) key(ctx context.Context, kid string, alg string) (interface{}, error) {
+	c.mu.Lock()
+	now := time.Now()
+	k, ok := c.keys[kid]
+	if c.canFetchLocked(now) {
+		switch {
+		case ok && now.Sub(c.fetchedAt) > c.ttl:
+			c.startFetchLocked()
+		case !ok && (c.keys == nil || now.Sub(c.fetchedAt) > c.minRefreshInterval):
+			// Wait for the fetch, as the token can't be verified without
+			// it.
+			call := c.startFetchLocked()
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			c.mu.Lock()
+			k, ok = c.keys[kid]
+		}
+	}
+	keys, fetchErr := c.keys, c.fetchErr
+	c.mu.Unlock()
+
+	if !ok {
+		// Report why there are no keys at all, if the server can't be
+		// reached.
+		if keys == nil && fetchErr != nil {
+			return nil, fmt.Errorf("%w: %s", ErrKeysUnavailable, fetchErr)
+		}
+		return nil, ErrUnknownKey
+	}
+	if k.alg != alg {
+		return nil, fmt.Errorf("%w: key %q is not for use with %s", ErrUnknownKey, kid, alg)
+	}
+	return k.key, nil
+}
+
+// Check whether a fetch may be started: none is in flight, and the last one
+// didn't fail within the minimum refresh interval.
+func (c *jwksCache) canFetchLocked(now time.Time) bool {
+	if c.inflight != nil {
+		return true
+	}
+	return c.fetchErr == nil || now.Sub(c.failedAt) > c.minRefreshInterval
+}
+
+// Start a fetch if one is not already in flight, and return the fetch to wait
+// on.
+func (c *jwksCache) startFetchLocked() *jwksFetch {
+	if c.inflight != nil {
+		return c.inflight
+	}
+	call := &jwksFetch{done: make(chan struct{})}
+	c.inflight = call
+
+	// The fetch is detached from the caller's context, so a cancelled request
+	// doesn't prevent the keys from being cached for the next one.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		keys, err := c.fetch(ctx)
+
+		c.mu.Lock()
+		c.inflight = nil
+		if err != nil {
+			// Keep using the cached keys if the server can't be reached.
+			c.failedAt = time.Now()
+			c.fetchErr = err
+		} else {
+			c.keys = keys
+			c.fetchedAt = time.Now()
+			c.fetchErr = nil
+		}
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+func (c *jwksCache) fetch(ctx context.Context) (map[string]cachedKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Drain the body so the connection can be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("failed to fetch JWKS: response status code %d", resp.StatusCode)
+	}
+
+	var set jwks
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]cachedKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		parsed, alg, err := k.parse()
+		if err != nil {
+			// Skip keys we don't understand, rather than failing outright.
+			continue
+		}
+		keys[k.Kid] = cachedKey{alg: alg, key: parsed}
+	}
+
+	return keys, nil
+}
+
+func (k jwk) parse() (interface{}, string, error) {
+	switch k.Kty {
+	case "RSA":
+		if k.Alg != "" && k.Alg != "RS256" {
+			return nil, "", fmt.Errorf("unsupported RSA algorithm %q", k.Alg)
+		}
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, "", err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, "", err
+		}
+		if !e.IsInt64() {
+			return nil, "", errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, "RS256", nil
+	case "EC":
+		if k.Crv != "P-256" || (k.Alg != "" && k.Alg != "ES256") {
+			return nil, "", fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, "", err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, "", err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, "", errors.New("EC point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, "ES256", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
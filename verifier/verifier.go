@@ -0,0 +1,206 @@
+// Package verifier validates GoTrue access tokens locally, without a request
+// to the GoTrue server.
+//
+// Tokens signed with HS256 are verified using the project's JWT secret. Tokens
+// signed with RS256 or ES256 are verified using the server's JSON Web Key Set,
+// which is fetched from /.well-known/jwks.json and cached.
+//
+// Verifying locally is much cheaper than calling GetUser on every request, but
+// note that it cannot detect sessions that have been revoked since the token
+// was issued, e.g. by logging out. Keep access token lifetimes short if that
+// matters to you.
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/supabase-community/gotrue-go/types"
+)
+
+const jwksPath = "/.well-known/jwks.json"
+
+var (
+	ErrNoKeys          = errors.New("cannot create verifier: a JWT secret or a GoTrue URL to fetch keys from must be provided")
+	ErrInvalidToken    = errors.New("token is invalid")
+	ErrTokenExpired    = errors.New("token has expired")
+	ErrTokenNotYet     = errors.New("token is not valid yet")
+	ErrInvalidAudience = errors.New("token has an invalid audience")
+	ErrInvalidIssuer   = errors.New("token has an invalid issuer")
+	ErrMissingSubject  = errors.New("token has no subject")
+	ErrUnknownKey      = errors.New("token is signed with an unknown key")
+	// ErrKeysUnavailable is returned if the JWKS is needed to verify a token
+	// but can't be fetched. It says nothing about the token itself.
+	ErrKeysUnavailable = errors.New("cannot fetch signing keys")
+)
+
+type Options struct {
+	// JWTSecret is the project's JWT secret, used to verify HS256 tokens. If
+	// empty, HS256 tokens are rejected.
+	JWTSecret []byte
+
+	// GoTrueURL is the URL of the GoTrue server, e.g.
+	// https://<project_ref>.supabase.co/auth/v1. If set, the JWKS is fetched
+	// from GoTrueURL/.well-known/jwks.json and used to verify RS256 and ES256
+	// tokens.
+	GoTrueURL string
+	// JWKSURL overrides the URL the JWKS is fetched from.
+	JWKSURL string
+
+	// Audience must be present in the token's "aud" claim. Defaults to
+	// "authenticated", the audience of user tokens issued by GoTrue.
+	Audience string
+	// Issuer, if set, must match the token's "iss" claim. Defaults to
+	// GoTrueURL, if that is set.
+	Issuer string
+	// AllowMissingSubject accepts tokens without a "sub" claim. By default
+	// they are rejected, as they don't belong to a user, e.g. the project's
+	// anon and service_role keys.
+	AllowMissingSubject bool
+	// Leeway allows for clock skew when checking "exp" and "nbf".
+	Leeway time.Duration
+
+	// HTTPClient is used to fetch the JWKS. Defaults to a client with a 10
+	// second timeout.
+	HTTPClient *http.Client
+	// CacheTTL is how long a fetched JWKS is used before it is fetched again.
+	// Defaults to 10 minutes.
+	//
+	// Regardless of the TTL, the JWKS is fetched again when a token is signed
+	// with a key that is not in the cache, so rotated keys are picked up
+	// straight away.
+	CacheTTL time.Duration
+	// MinRefreshInterval limits how often the JWKS is fetched because of an
+	// unknown key, so that tokens with made up key IDs can't be used to flood
+	// the server, and how often it is retried after a fetch fails. Defaults to
+	// 30 seconds.
+	MinRefreshInterval time.Duration
+}
+
+// Verifier validates GoTrue access tokens. It is safe for concurrent use.
+type Verifier struct {
+	opts   Options
+	jwks   *jwksCache
+	parser *jwt.Parser
+}
+
+// Create a new verifier. At least one of JWTSecret, GoTrueURL or JWKSURL must
+// be set.
+func New(opts Options) (*Verifier, error) {
+	if opts.GoTrueURL != "" {
+		gotrueURL := strings.TrimSuffix(opts.GoTrueURL, "/")
+		if opts.JWKSURL == "" {
+			opts.JWKSURL = gotrueURL + jwksPath
+		}
+		if opts.Issuer == "" {
+			opts.Issuer = gotrueURL
+		}
+	}
+	if opts.Audience == "" {
+		opts.Audience = "authenticated"
+	}
+	if len(opts.JWTSecret) == 0 && opts.JWKSURL == "" {
+		return nil, ErrNoKeys
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 10 * time.Minute
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = 30 * time.Second
+	}
+
+	v := &Verifier{
+		opts: opts,
+		// Claims are validated by Verify so that leeway, audience and issuer
+		// can be handled consistently.
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{"HS256", "RS256", "ES256"}),
+			jwt.WithoutClaimsValidation(),
+		),
+	}
+	if opts.JWKSURL != "" {
+		v.jwks = newJWKSCache(opts.JWKSURL, opts.HTTPClient, opts.CacheTTL, opts.MinRefreshInterval)
+	}
+	return v, nil
+}
+
+// claims adapts types.Claims to the jwt.Claims interface. Validation is done
+// by Verify instead.
+type claims struct {
+	types.Claims
+}
+
+func (claims) Valid() error {
+	return nil
+}
+
+// Verify the token's signature and claims, and return the claims if it is
+// valid.
+//
+// If the JWKS needs to be fetched, Verify waits for it until the context is
+// done, and then returns the context's error. If it can't be fetched, the
+// error wraps ErrKeysUnavailable. All other errors mean the token is invalid.
+func (v *Verifier) Verify(ctx context.Context, token string) (*types.Claims, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.Alg() {
+		case "HS256":
+			if len(v.opts.JWTSecret) == 0 {
+				return nil, ErrUnknownKey
+			}
+			return v.opts.JWTSecret, nil
+		default:
+			if v.jwks == nil {
+				return nil, ErrUnknownKey
+			}
+			kid, _ := t.Header["kid"].(string)
+			return v.jwks.key(ctx, kid, t.Method.Alg())
+		}
+	})
+	if err != nil {
+		var vErr *jwt.ValidationError
+		if errors.As(err, &vErr) && vErr.Inner != nil {
+			err = vErr.Inner
+		}
+		if errors.Is(err, ErrUnknownKey) || errors.Is(err, ErrKeysUnavailable) || (ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	if err := v.validate(&c.Claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return &c.Claims, nil
+}
+
+func (v *Verifier) validate(c *types.Claims, now time.Time) error {
+	if c.Expiry == 0 {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if now.After(time.Unix(c.Expiry, 0).Add(v.opts.Leeway)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Before(time.Unix(c.NotBefore, 0).Add(-v.opts.Leeway)) {
+		return ErrTokenNotYet
+	}
+	if c.Subject == "" && !v.opts.AllowMissingSubject {
+		return ErrMissingSubject
+	}
+	if !c.Audience.Contains(v.opts.Audience) {
+		return ErrInvalidAudience
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return ErrInvalidIssuer
+	}
+	return nil
+}
@@ -0,0 +1,381 @@
+package verifier_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supabase-community/gotrue-go/verifier"
+)
+
+// jwksServer serves a JWKS that can be changed during a test.
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches int
+	// If set, responses wait until it is closed.
+	block chan struct{}
+	// If set, responses fail with this status code.
+	status int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.fetches++
+	block, status, keys := s.block, s.status, s.keys
+	s.mu.Unlock()
+
+	if block != nil {
+		<-block
+	}
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func (s *jwksServer) setKeys(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"alg": "RS256",
+		"use": "sig",
+		"n":   b64(key.N.Bytes()),
+		"e":   b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"alg": "ES256",
+		"crv": "P-256",
+		"x":   b64(key.X.Bytes()),
+		"y":   b64(key.Y.Bytes()),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func userClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":          "8b0b6a0e-8a43-4d67-9f8c-4b3c2a1d0e9f",
+		"aud":          "authenticated",
+		"iss":          "http://localhost:9999",
+		"exp":          time.Now().Add(time.Hour).Unix(),
+		"role":         "authenticated",
+		"aal":          "aal1",
+		"amr":          []map[string]interface{}{{"method": "password", "timestamp": 1700000000}},
+		"session_id":   "2f7b1e0c-2b0b-4c36-8b5b-0d6f2d6c1a11",
+		"is_anonymous": false,
+		"app_metadata": map[string]interface{}{"provider": "email"},
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	_, err := verifier.New(verifier.Options{})
+	assert.ErrorIs(err, verifier.ErrNoKeys)
+
+	v, err := verifier.New(verifier.Options{
+		JWTSecret: []byte("secret"),
+		Audience:  "authenticated",
+		Issuer:    "http://localhost:9999",
+	})
+	require.NoError(err)
+
+	claims, err := v.Verify(ctx, sign(t, jwt.SigningMethodHS256, "", []byte("secret"), userClaims()))
+	require.NoError(err)
+	assert.Equal("8b0b6a0e-8a43-4d67-9f8c-4b3c2a1d0e9f", claims.Subject)
+	assert.Equal("authenticated", claims.Role)
+	assert.Equal("2f7b1e0c-2b0b-4c36-8b5b-0d6f2d6c1a11", claims.SessionID)
+	assert.Equal("email", claims.AppMetadata["provider"])
+	require.Len(claims.AMR, 1)
+	assert.Equal("password", claims.AMR[0].Method)
+
+	// Wrong secret
+	_, err = v.Verify(ctx, sign(t, jwt.SigningMethodHS256, "", []byte("wrong"), userClaims()))
+	assert.ErrorIs(err, verifier.ErrInvalidToken)
+
+	// Garbage
+	_, err = v.Verify(ctx, "not.a.token")
+	assert.ErrorIs(err, verifier.ErrInvalidToken)
+
+	// Unsupported algorithm
+	_, err = v.Verify(ctx, sign(t, jwt.SigningMethodHS512, "", []byte("secret"), userClaims()))
+	assert.ErrorIs(err, verifier.ErrInvalidToken)
+
+	tests := map[string]struct {
+		modify func(jwt.MapClaims)
+		err    error
+	}{
+		"expired": {
+			modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+			err:    verifier.ErrTokenExpired,
+		},
+		"missing_exp": {
+			modify: func(c jwt.MapClaims) { delete(c, "exp") },
+			err:    verifier.ErrInvalidToken,
+		},
+		"not_yet_valid": {
+			modify: func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Minute).Unix() },
+			err:    verifier.ErrTokenNotYet,
+		},
+		"missing_subject": {
+			modify: func(c jwt.MapClaims) { delete(c, "sub") },
+			err:    verifier.ErrMissingSubject,
+		},
+		"wrong_audience": {
+			modify: func(c jwt.MapClaims) { c["aud"] = []string{"other"} },
+			err:    verifier.ErrInvalidAudience,
+		},
+		"wrong_issuer": {
+			modify: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+			err:    verifier.ErrInvalidIssuer,
+		},
+	}
+	for name, test := range tests {
+		c := userClaims()
+		test.modify(c)
+		_, err := v.Verify(ctx, sign(t, jwt.SigningMethodHS256, "", []byte("secret"), c))
+		assert.ErrorIs(err, test.err, name)
+	}
+
+	// Leeway allows for clock skew
+	v, err = verifier.New(verifier.Options{
+		JWTSecret: []byte("secret"),
+		Leeway:    2 * time.Minute,
+	})
+	require.NoError(err)
+	c := userClaims()
+	c["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, err = v.Verify(ctx, sign(t, jwt.SigningMethodHS256, "", []byte("secret"), c))
+	assert.NoError(err)
+
+	// The audience defaults to "authenticated"
+	c = userClaims()
+	c["aud"] = "other"
+	_, err = v.Verify(ctx, sign(t, jwt.SigningMethodHS256, "", []byte("secret"), c))
+	assert.ErrorIs(err, verifier.ErrInvalidAudience)
+}
+
+func TestVerifyAPIKeys(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	// The project's API keys are signed with the JWT secret, but have no
+	// subject or audience.
+	apiKey := func(role string) string {
+		return sign(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+			"iss":  "supabase",
+			"ref":  "abcdefghijklmnopqrst",
+			"role": role,
+			"iat":  time.Now().Unix(),
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+	}
+
+	v, err := verifier.New(verifier.Options{JWTSecret: []byte("secret")})
+	require.NoError(err)
+	for _, role := range []string{"anon", "service_role"} {
+		_, err = v.Verify(ctx, apiKey(role))
+		assert.ErrorIs(err, verifier.ErrMissingSubject, role)
+	}
+
+	// Allowing a missing subject still checks the audience.
+	v, err = verifier.New(verifier.Options{
+		JWTSecret:           []byte("secret"),
+		AllowMissingSubject: true,
+	})
+	require.NoError(err)
+	_, err = v.Verify(ctx, apiKey("anon"))
+	assert.ErrorIs(err, verifier.ErrInvalidAudience)
+}
+
+func TestVerifyJWKS(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	rotatedKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+
+	keys := &jwksServer{}
+	keys.setKeys(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+	mux := http.NewServeMux()
+	mux.Handle("/auth/v1/.well-known/jwks.json", keys)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// The issuer defaults to the GoTrue URL.
+	claimsFor := func() jwt.MapClaims {
+		c := userClaims()
+		c["iss"] = srv.URL + "/auth/v1"
+		return c
+	}
+
+	v, err := verifier.New(verifier.Options{
+		GoTrueURL:          srv.URL + "/auth/v1/",
+		MinRefreshInterval: time.Millisecond,
+	})
+	require.NoError(err)
+
+	claims, err := v.Verify(ctx, sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claimsFor()))
+	require.NoError(err)
+	assert.Equal("authenticated", claims.Role)
+
+	claims, err = v.Verify(ctx, sign(t, jwt.SigningMethodES256, "ec-1", ecKey, claimsFor()))
+	require.NoError(err)
+	assert.Equal("authenticated", claims.Role)
+
+	// Keys are cached
+	assert.Equal(1, keys.fetchCount())
+
+	// Tokens from another issuer are rejected
+	c := claimsFor()
+	c["iss"] = "http://localhost:9999"
+	_, err = v.Verify(ctx, sign(t, jwt.SigningMethodES256, "ec-1", ecKey, c))
+	assert.ErrorIs(err, verifier.ErrInvalidIssuer)
+
+	// Key ID does not match the signing key
+	_, err = v.Verify(ctx, sign(t, jwt.SigningMethodES256, "rsa-1", ecKey, claimsFor()))
+	assert.ErrorIs(err, verifier.ErrUnknownKey)
+
+	// HS256 is rejected without a secret
+	_, err = v.Verify(ctx, sign(t, jwt.SigningMethodHS256, "", []byte("secret"), claimsFor()))
+	assert.ErrorIs(err, verifier.ErrUnknownKey)
+
+	// Rotated keys are fetched when first seen
+	time.Sleep(2 * time.Millisecond)
+	keys.setKeys(ecJWK("ec-2", rotatedKey))
+	_, err = v.Verify(ctx, sign(t, jwt.SigningMethodES256, "ec-2", rotatedKey, claimsFor()))
+	require.NoError(err)
+
+	// Unknown keys are rejected, and don't cause a fetch within the minimum
+	// refresh interval.
+	v, err = verifier.New(verifier.Options{
+		JWKSURL:            srv.URL + "/auth/v1/.well-known/jwks.json",
+		MinRefreshInterval: time.Hour,
+	})
+	require.NoError(err)
+	before := keys.fetchCount()
+	for i := 0; i < 3; i++ {
+		_, err = v.Verify(ctx, sign(t, jwt.SigningMethodES256, "unknown", ecKey, claimsFor()))
+		assert.ErrorIs(err, verifier.ErrUnknownKey)
+	}
+	assert.Equal(before+1, keys.fetchCount())
+}
+
+func TestVerifyJWKSFetch(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	token := sign(t, jwt.SigningMethodES256, "ec-1", key, userClaims())
+
+	block := make(chan struct{})
+	keys := &jwksServer{block: block}
+	keys.setKeys(ecJWK("ec-1", key))
+	srv := httptest.NewServer(keys)
+	t.Cleanup(srv.Close)
+
+	v, err := verifier.New(verifier.Options{
+		JWKSURL:            srv.URL,
+		MinRefreshInterval: time.Hour,
+	})
+	require.NoError(err)
+
+	// A cancelled request doesn't cancel the fetch, or prevent the next
+	// request from fetching the keys.
+	cancelled, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = v.Verify(cancelled, token)
+	assert.ErrorIs(err, context.Canceled)
+
+	// Concurrent requests wait for the same fetch.
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = v.Verify(ctx, token)
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(block)
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(err)
+	}
+	assert.Equal(1, keys.fetchCount())
+
+	// A failed fetch is not retried within the minimum refresh interval.
+	keys.mu.Lock()
+	keys.status = http.StatusServiceUnavailable
+	keys.mu.Unlock()
+	v, err = verifier.New(verifier.Options{
+		JWKSURL:            srv.URL,
+		MinRefreshInterval: time.Hour,
+	})
+	require.NoError(err)
+	for i := 0; i < 3; i++ {
+		_, err = v.Verify(ctx, token)
+		assert.ErrorIs(err, verifier.ErrKeysUnavailable)
+		assert.NotErrorIs(err, verifier.ErrInvalidToken)
+	}
+	assert.Equal(2, keys.fetchCount())
+}